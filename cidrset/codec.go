@@ -0,0 +1,203 @@
+package cidrset
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+)
+
+// WireVersion is the version of the binary format written by Marshal.
+// Unmarshal reads every version up to and including it.
+//
+// Version 1 is the MessagePack array
+//
+//	["cidrset", 1, [set, ...]]
+//
+// where each set is a map with the keys
+//
+//	"name"     string
+//	"meta"     map of string to string
+//	"networks" array of bin: the prefix length followed by the 4 (IPv4) or
+//	           16 (IPv6) bytes of the network address
+//
+// Readers ignore keys and trailing array elements they don't know, so fields
+// can be added without a new version. Changes that older readers would
+// misinterpret increase the version.
+const WireVersion = 1
+
+// wireMagic identifies the encoding so that other MessagePack data is
+// rejected rather than misread.
+const wireMagic = "cidrset"
+
+// FormatError reports data that is not a valid cidrset encoding.
+type FormatError struct {
+	Msg string
+}
+
+func (e *FormatError) Error() string {
+	return "cidrset: " + e.Msg
+}
+
+// Marshal encodes sets in the current wire format. Equal sets always
+// encode to the same bytes.
+func Marshal(sets ...*Set) []byte {
+	e := &encoder{}
+	e.array(3)
+	e.str(wireMagic)
+	e.uint(WireVersion)
+
+	e.array(len(sets))
+	for _, s := range sets {
+		e.mapHeader(3)
+
+		e.str("name")
+		e.str(s.Name)
+
+		e.str("meta")
+		keys := make([]string, 0, len(s.Meta))
+		for key := range s.Meta {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		e.mapHeader(len(keys))
+		for _, key := range keys {
+			e.str(key)
+			e.str(s.Meta[key])
+		}
+
+		e.str("networks")
+		e.array(len(s.networks))
+		for _, n := range s.networks {
+			e.bin(append([]byte{byte(n.Bits())}, n.Addr().AsSlice()...))
+		}
+	}
+
+	return e.buf
+}
+
+// Unmarshal decodes sets written by Marshal. Networks are put back into
+// canonical order, so data produced by other writers is accepted as long
+// as every network is valid.
+func Unmarshal(data []byte) ([]*Set, error) {
+	d := &decoder{data: data}
+
+	fields, err := d.count(kindArray)
+	if err != nil || fields < 3 {
+		return nil, &FormatError{Msg: "not a cidrset encoding"}
+	}
+	if magic, err := d.str(); err != nil || magic != wireMagic {
+		return nil, &FormatError{Msg: "not a cidrset encoding"}
+	}
+	version, err := d.uint()
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 || version > WireVersion {
+		return nil, &FormatError{Msg: fmt.Sprintf("unsupported wire version %d (this build reads up to %d)", version, WireVersion)}
+	}
+
+	count, err := d.count(kindArray)
+	if err != nil {
+		return nil, err
+	}
+	sets := make([]*Set, 0, count)
+	for range count {
+		s, err := d.set()
+		if err != nil {
+			return nil, fmt.Errorf("%w (set %d)", err, len(sets)+1)
+		}
+		sets = append(sets, s)
+	}
+
+	for range fields - 3 {
+		if err := d.skip(0); err != nil {
+			return nil, err
+		}
+	}
+	if d.pos != len(d.data) {
+		return nil, &FormatError{Msg: fmt.Sprintf("%d bytes of trailing data", len(d.data)-d.pos)}
+	}
+
+	return sets, nil
+}
+
+func (d *decoder) set() (*Set, error) {
+	pairs, err := d.count(kindMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var name string
+	meta := make(map[string]string)
+	var networks []Network
+	for range pairs {
+		key, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "name":
+			name, err = d.str()
+		case "meta":
+			err = d.meta(meta)
+		case "networks":
+			networks, err = d.networks()
+		default:
+			err = d.skip(0)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := NewSet(name, networks...)
+	s.Meta = meta
+	return s, nil
+}
+
+func (d *decoder) meta(meta map[string]string) error {
+	pairs, err := d.count(kindMap)
+	if err != nil {
+		return err
+	}
+	for range pairs {
+		key, err := d.str()
+		if err != nil {
+			return err
+		}
+		if meta[key], err = d.str(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) networks() ([]Network, error) {
+	count, err := d.count(kindArray)
+	if err != nil {
+		return nil, err
+	}
+	networks := make([]Network, 0, count)
+	for range count {
+		b, err := d.bin()
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != 1+4 && len(b) != 1+16 {
+			return nil, &FormatError{Msg: fmt.Sprintf("network of %d bytes", len(b))}
+		}
+
+		addr, _ := netip.AddrFromSlice(b[1:])
+		bits := int(b[0])
+		if bits > addr.BitLen() {
+			return nil, &FormatError{Msg: fmt.Sprintf("prefix length %d for %s", bits, addr)}
+		}
+		n, err := NetworkFromPrefix(netip.PrefixFrom(addr, bits))
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, n)
+	}
+	return networks, nil
+}
@@ -0,0 +1,243 @@
+package cidrset
+
+import (
+	"bytes"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func mustSet(t *testing.T, name string, entries ...string) *Set {
+	t.Helper()
+	s, err := ParseSet(name, entries)
+	if err != nil {
+		t.Fatalf("ParseSet: %v", err)
+	}
+	return s
+}
+
+// sampleSets covers both families, IPv4-mapped input, metadata and an
+// empty set.
+func sampleSets(t *testing.T) []*Set {
+	office := mustSet(t, "office", "10.2.0.0/16", "10.1.0.0/16", "192.0.2.1")
+	office.Meta["port"] = "443"
+	office.Meta["label.env"] = "prod"
+	return []*Set{
+		office,
+		mustSet(t, "v6", "2001:db8::/32", "2001:db8:1::/48", "::1"),
+		mustSet(t, "mapped", "::ffff:10.0.0.0/104", "::ffff:192.0.2.1"),
+		mustSet(t, "empty"),
+		mustSet(t, ""),
+	}
+}
+
+// header starts an encoding with the given magic, version and set count.
+func header(magic string, version uint64, sets int) *encoder {
+	e := &encoder{}
+	e.array(3)
+	e.str(magic)
+	e.uint(version)
+	e.array(sets)
+	return e
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	sets := sampleSets(t)
+	decoded, err := Unmarshal(Marshal(sets...))
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(decoded) != len(sets) {
+		t.Fatalf("decoded %d sets, want %d", len(decoded), len(sets))
+	}
+	for i := range sets {
+		if !sets[i].Equal(decoded[i]) {
+			t.Errorf("set %d: decoded %q %v %q, want %q %v %q", i,
+				decoded[i].Name, decoded[i].Meta, decoded[i], sets[i].Name, sets[i].Meta, sets[i])
+		}
+	}
+
+	// IPv4-mapped input is stored as IPv4
+	if got := decoded[2].String(); got != "10.0.0.0/8\n192.0.2.1/32\n" {
+		t.Errorf("mapped set = %q", got)
+	}
+
+	if decoded, err := Unmarshal(Marshal()); err != nil || len(decoded) != 0 {
+		t.Errorf("no sets: decoded %v, %v", decoded, err)
+	}
+}
+
+func TestMarshalDeterministic(t *testing.T) {
+	want := Marshal(sampleSets(t)...)
+	for range 10 {
+		if got := Marshal(sampleSets(t)...); !bytes.Equal(got, want) {
+			t.Fatalf("Marshal is not deterministic:\n%x\n%x", got, want)
+		}
+	}
+
+	// Input order and duplicates don't matter
+	a := mustSet(t, "x", "10.0.0.0/8", "2001:db8::/32", "10.0.0.0/8")
+	b := mustSet(t, "x", "2001:db8::/32", "10.1.2.3/8")
+	if !bytes.Equal(Marshal(a), Marshal(b)) {
+		t.Errorf("equal sets encode differently:\n%x\n%x", Marshal(a), Marshal(b))
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	valid := Marshal(sampleSets(t)...)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not msgpack", []byte("10.0.0.0/8\n")},
+		{"wrong magic", header("cidrsex", 1, 0).buf},
+		{"magic not a string", func() []byte {
+			e := &encoder{}
+			e.array(3)
+			e.uint(1)
+			e.uint(1)
+			e.array(0)
+			return e.buf
+		}()},
+		{"short header", func() []byte {
+			e := &encoder{}
+			e.array(2)
+			e.str(wireMagic)
+			e.uint(1)
+			return e.buf
+		}()},
+		{"version 0", header(wireMagic, 0, 0).buf},
+		{"future version", header(wireMagic, WireVersion+1, 0).buf},
+		{"truncated", valid[:len(valid)-3]},
+		{"truncated header", valid[:5]},
+		{"trailing bytes", append(bytes.Clone(valid), 0xc0)},
+		{"set count past the end", header(wireMagic, 1, 1000).buf},
+		{"network of 3 bytes", func() []byte {
+			e := header(wireMagic, 1, 1)
+			e.mapHeader(1)
+			e.str("networks")
+			e.array(1)
+			e.bin([]byte{8, 10, 0})
+			return e.buf
+		}()},
+		{"prefix too long", func() []byte {
+			e := header(wireMagic, 1, 1)
+			e.mapHeader(1)
+			e.str("networks")
+			e.array(1)
+			e.bin([]byte{33, 10, 0, 0, 0})
+			return e.buf
+		}()},
+		{"invalid type byte", func() []byte {
+			e := header(wireMagic, 1, 1)
+			e.mapHeader(1)
+			e.str("extra")
+			e.buf = append(e.buf, 0xc1)
+			return e.buf
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sets, err := Unmarshal(tt.data)
+			var formatErr *FormatError
+			if !errors.As(err, &formatErr) {
+				t.Errorf("Unmarshal = %v, %v; want a *FormatError", sets, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalSkipsUnknownFields(t *testing.T) {
+	e := &encoder{}
+	e.array(5)
+	e.str(wireMagic)
+	e.uint(WireVersion)
+	e.array(1)
+	e.mapHeader(5)
+	e.str("comment")
+	e.str("added by a newer writer")
+	e.str("name")
+	e.str("office")
+	e.str("stats")
+	e.mapHeader(2)
+	e.str("hits")
+	e.uint(1 << 40)
+	e.str("history")
+	e.array(5)
+	e.bin([]byte{1, 2, 3})
+	e.buf = append(e.buf, 0xc0, 0xc3, 0xd0, 0xff, 0xcb, 0, 0, 0, 0, 0, 0, 0, 0) // nil, true, int8, float64
+	e.str("networks")
+	e.array(1)
+	e.bin([]byte{24, 192, 0, 2, 0})
+	e.str("meta")
+	e.mapHeader(1)
+	e.str("port")
+	e.str("443")
+	// Trailing top-level fields
+	e.mapHeader(0)
+	e.buf = append(e.buf, 0xd5, 1, 0xaa, 0xbb) // fixext 2
+
+	sets, err := Unmarshal(e.buf)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := mustSet(t, "office", "192.0.2.0/24")
+	want.Meta["port"] = "443"
+	if len(sets) != 1 || !sets[0].Equal(want) {
+		t.Errorf("Unmarshal = %v, want %v", sets, want)
+	}
+}
+
+func TestUnmarshalDepthLimit(t *testing.T) {
+	nested := func(depth int) []byte {
+		e := header(wireMagic, 1, 1)
+		e.mapHeader(1)
+		e.str("deep")
+		for range depth {
+			e.array(1)
+		}
+		e.uint(0)
+		return e.buf
+	}
+
+	if _, err := Unmarshal(nested(maxDepth)); err != nil {
+		t.Errorf("nesting of %d: %v", maxDepth, err)
+	}
+	_, err := Unmarshal(nested(maxDepth + 1))
+	var formatErr *FormatError
+	if !errors.As(err, &formatErr) {
+		t.Errorf("nesting of %d: error = %v, want a *FormatError", maxDepth+1, err)
+	}
+}
+
+func TestSetBinary(t *testing.T) {
+	want := sampleSets(t)[0]
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	var got Set
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("UnmarshalBinary = %v, want %v", &got, want)
+	}
+
+	// Data holding several sets (or none) is not a single set
+	for _, data := range [][]byte{Marshal(sampleSets(t)...), Marshal()} {
+		var s Set
+		err := s.UnmarshalBinary(data)
+		var formatErr *FormatError
+		if !errors.As(err, &formatErr) {
+			t.Errorf("UnmarshalBinary of %d bytes: error = %v, want a *FormatError", len(data), err)
+		}
+	}
+
+	if !got.Contains(netip.MustParseAddr("10.1.2.3")) {
+		t.Errorf("decoded set does not contain 10.1.2.3")
+	}
+}
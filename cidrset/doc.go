@@ -0,0 +1,17 @@
+// Package cidrset provides IP networks and named sets of networks with a
+// canonical text form and a stable, versioned binary encoding.
+//
+// A Set is kept in canonical order, so equal lists of ranges always print
+// and encode identically regardless of how the input was written:
+//
+//	set, err := cidrset.ParseSet("office", []string{"10.2.0.0/16", "10.1.0.0/16"})
+//	fmt.Print(set) // 10.1.0.0/16\n10.2.0.0/16\n
+//
+// Set.Contains checks each network in turn; for large lists, a Trie finds
+// every network containing an address in one pass over its bits.
+//
+// Marshal and Unmarshal convert sets to and from a compact MessagePack
+// encoding (see WireVersion) so services can cache compiled range sets or
+// ship them between processes without reparsing text. The cidr command
+// writes this format with 'cidr compile'.
+package cidrset
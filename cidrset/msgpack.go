@@ -0,0 +1,254 @@
+package cidrset
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// A minimal MessagePack (https://msgpack.org) encoder and decoder for the
+// types the wire format uses. The decoder can skip any well-formed value so
+// that fields added by newer writers are ignored.
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) uint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		e.buf = append(e.buf, byte(v))
+	case v <= 0xff:
+		e.buf = append(e.buf, 0xcc, byte(v))
+	case v <= 0xffff:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(v))
+	case v <= 0xffffffff:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(v))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), v)
+	}
+}
+
+func (e *encoder) str(s string) {
+	e.header(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) bin(b []byte) {
+	e.header(len(b), 0, -1, 0xc4, 0xc5, 0xc6)
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) array(n int) {
+	e.header(n, 0x90, 15, 0, 0xdc, 0xdd)
+}
+
+func (e *encoder) mapHeader(n int) {
+	e.header(n, 0x80, 15, 0, 0xde, 0xdf)
+}
+
+// header writes a length using the smallest form available: a fix type
+// (when fixMax >= 0), then 8, 16 or 32-bit lengths (a zero code means the
+// type has no 8-bit form).
+func (e *encoder) header(n int, fix byte, fixMax int, code8, code16, code32 byte) {
+	switch {
+	case n <= fixMax:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= 0xff && code8 != 0:
+		e.buf = append(e.buf, code8, byte(n))
+	case n <= 0xffff:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, code16), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, code32), uint32(n))
+	}
+}
+
+// kind is the type of a decoded MessagePack value.
+type kind int
+
+const (
+	kindNil kind = iota
+	kindBool
+	kindUint
+	kindInt
+	kindFloat
+	kindStr
+	kindBin
+	kindArray
+	kindMap
+	kindExt
+)
+
+var kindNames = [...]string{"nil", "bool", "unsigned integer", "integer", "float", "string", "binary", "array", "map", "extension"}
+
+func (k kind) String() string {
+	return kindNames[k]
+}
+
+// sizedTypes maps type bytes followed by a length or value to their kind
+// and the number of bytes that follow.
+var sizedTypes = map[byte]struct {
+	kind kind
+	size uint64
+}{
+	0xc4: {kindBin, 1}, 0xc5: {kindBin, 2}, 0xc6: {kindBin, 4},
+	0xc7: {kindExt, 1}, 0xc8: {kindExt, 2}, 0xc9: {kindExt, 4},
+	0xca: {kindFloat, 4}, 0xcb: {kindFloat, 8},
+	0xcc: {kindUint, 1}, 0xcd: {kindUint, 2}, 0xce: {kindUint, 4}, 0xcf: {kindUint, 8},
+	0xd0: {kindInt, 1}, 0xd1: {kindInt, 2}, 0xd2: {kindInt, 4}, 0xd3: {kindInt, 8},
+	0xd9: {kindStr, 1}, 0xda: {kindStr, 2}, 0xdb: {kindStr, 4},
+	0xdc: {kindArray, 2}, 0xdd: {kindArray, 4},
+	0xde: {kindMap, 2}, 0xdf: {kindMap, 4},
+}
+
+// maxDepth limits nesting when skipping values from untrusted input.
+const maxDepth = 32
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, &FormatError{Msg: "unexpected end of data"}
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// readUint reads a big-endian unsigned integer of 1, 2, 4 or 8 bytes.
+func (d *decoder) readUint(size uint64) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// next reads the header of the next value. Scalars are consumed whole and
+// n holds their value (or is unused); for strings, binaries and extensions
+// n is the payload length left to read; for arrays and maps n is the
+// number of elements or key-value pairs.
+func (d *decoder) next() (k kind, n uint64, err error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return kindUint, uint64(c), nil
+	case c <= 0x8f:
+		return kindMap, uint64(c & 0x0f), nil
+	case c <= 0x9f:
+		return kindArray, uint64(c & 0x0f), nil
+	case c <= 0xbf:
+		return kindStr, uint64(c & 0x1f), nil
+	case c >= 0xe0:
+		return kindInt, uint64(c), nil
+	}
+
+	switch c {
+	case 0xc0:
+		return kindNil, 0, nil
+	case 0xc2, 0xc3:
+		return kindBool, uint64(c & 1), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		// fixext 1, 2, 4, 8 and 16: a type byte, then the payload
+		if _, err := d.read(1); err != nil {
+			return 0, 0, err
+		}
+		return kindExt, 1 << (c - 0xd4), nil
+	}
+
+	t, ok := sizedTypes[c]
+	if !ok {
+		return 0, 0, &FormatError{Msg: fmt.Sprintf("invalid MessagePack type byte 0x%02x", c)}
+	}
+	n, err = d.readUint(t.size)
+	if err != nil {
+		return 0, 0, err
+	}
+	if t.kind == kindExt {
+		// Extension type byte
+		if _, err := d.read(1); err != nil {
+			return 0, 0, err
+		}
+	}
+	return t.kind, n, nil
+}
+
+// expect reads the next header and fails unless it is of kind k.
+func (d *decoder) expect(k kind) (uint64, error) {
+	got, n, err := d.next()
+	if err != nil {
+		return 0, err
+	}
+	if got != k {
+		return 0, &FormatError{Msg: fmt.Sprintf("expected %s, found %s", k, got)}
+	}
+	return n, nil
+}
+
+func (d *decoder) uint() (uint64, error) {
+	return d.expect(kindUint)
+}
+
+func (d *decoder) str() (string, error) {
+	n, err := d.expect(kindStr)
+	if err != nil {
+		return "", err
+	}
+	b, err := d.read(n)
+	return string(b), err
+}
+
+func (d *decoder) bin() ([]byte, error) {
+	n, err := d.expect(kindBin)
+	if err != nil {
+		return nil, err
+	}
+	return d.read(n)
+}
+
+// count reads an array or map header and checks the element count against
+// the remaining data, so a corrupt header cannot cause a huge allocation.
+func (d *decoder) count(k kind) (int, error) {
+	n, err := d.expect(k)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, &FormatError{Msg: "unexpected end of data"}
+	}
+	return int(n), nil
+}
+
+// skip reads past the next value, whatever its type.
+func (d *decoder) skip(depth int) error {
+	if depth > maxDepth {
+		return &FormatError{Msg: "values nested too deeply"}
+	}
+	k, n, err := d.next()
+	if err != nil {
+		return err
+	}
+	switch k {
+	case kindStr, kindBin, kindExt:
+		_, err = d.read(n)
+	case kindArray, kindMap:
+		if k == kindMap {
+			n *= 2
+		}
+		for i := uint64(0); i < n && err == nil; i++ {
+			err = d.skip(depth + 1)
+		}
+	}
+	return err
+}
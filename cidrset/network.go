@@ -0,0 +1,118 @@
+package cidrset
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Network is an IP network in canonical form: host bits are cleared and
+// IPv4-mapped IPv6 networks are stored as IPv4. The zero Network is invalid.
+type Network struct {
+	prefix netip.Prefix
+}
+
+// ParseNetwork parses a CIDR such as "10.1.2.0/24", or a single address as
+// a /32 or /128 network. Host bits are cleared, so "10.1.2.3/24" parses as
+// 10.1.2.0/24.
+func ParseNetwork(s string) (Network, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return Network{}, fmt.Errorf("cidrset: invalid network '%s': %w", s, err)
+		}
+		return NetworkFromPrefix(netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return Network{}, fmt.Errorf("cidrset: invalid network '%s': %w", s, err)
+	}
+	return NetworkFromPrefix(prefix)
+}
+
+// NetworkFromPrefix returns the canonical network for a prefix.
+func NetworkFromPrefix(prefix netip.Prefix) (Network, error) {
+	if !prefix.IsValid() {
+		return Network{}, fmt.Errorf("cidrset: invalid prefix %s", prefix)
+	}
+	addr, bits := prefix.Addr().WithZone(""), prefix.Bits()
+	if addr.Is4In6() && bits >= 96 {
+		addr, bits = addr.Unmap(), bits-96
+	}
+	return Network{prefix: netip.PrefixFrom(addr, bits).Masked()}, nil
+}
+
+// Prefix returns the network as a netip.Prefix.
+func (n Network) Prefix() netip.Prefix {
+	return n.prefix
+}
+
+// Addr returns the network address.
+func (n Network) Addr() netip.Addr {
+	return n.prefix.Addr()
+}
+
+// Bits returns the prefix length.
+func (n Network) Bits() int {
+	return n.prefix.Bits()
+}
+
+// IsValid reports whether the network is not the zero Network.
+func (n Network) IsValid() bool {
+	return n.prefix.IsValid()
+}
+
+// Contains reports whether addr is in the network. IPv4-mapped IPv6
+// addresses match IPv4 networks.
+func (n Network) Contains(addr netip.Addr) bool {
+	return n.prefix.Contains(addr.Unmap().WithZone(""))
+}
+
+// Overlaps reports whether the networks share any address.
+func (n Network) Overlaps(o Network) bool {
+	return n.prefix.Overlaps(o.prefix)
+}
+
+// Compare orders networks with IPv4 before IPv6, then by address, then
+// from the shortest prefix to the longest so that a network sorts before
+// the networks it contains.
+func (n Network) Compare(o Network) int {
+	if c := n.prefix.Addr().Compare(o.prefix.Addr()); c != 0 {
+		return c
+	}
+	return n.prefix.Bits() - o.prefix.Bits()
+}
+
+// String returns the canonical text form, e.g. "10.1.2.0/24" or
+// "2001:db8::/32".
+func (n Network) String() string {
+	if !n.IsValid() {
+		return "invalid Network"
+	}
+	return n.prefix.String()
+}
+
+// MarshalText implements encoding.TextMarshaler using the canonical form.
+func (n Network) MarshalText() ([]byte, error) {
+	if !n.IsValid() {
+		return []byte{}, nil
+	}
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. An empty text
+// produces the zero Network.
+func (n *Network) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*n = Network{}
+		return nil
+	}
+	network, err := ParseNetwork(string(text))
+	if err != nil {
+		return err
+	}
+	*n = network
+	return nil
+}
@@ -0,0 +1,86 @@
+package cidrset
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParseNetwork(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"10.1.2.0/24", "10.1.2.0/24"},
+		{"10.1.2.3/24", "10.1.2.0/24"},
+		{" 10.1.2.3 ", "10.1.2.3/32"},
+		{"0.0.0.0/0", "0.0.0.0/0"},
+		{"2001:db8::1/32", "2001:db8::/32"},
+		{"2001:DB8::1", "2001:db8::1/128"},
+		{"fe80::1%eth0", "fe80::1/128"},
+		{"::ffff:10.1.2.3/120", "10.1.2.0/24"},
+		{"::ffff:192.0.2.1", "192.0.2.1/32"},
+		{"::ffff:0:0/96", "0.0.0.0/0"},
+		{"::ffff:0:0/80", "::/80"}, // shorter than the mapped prefix stays IPv6
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			n, err := ParseNetwork(tt.in)
+			if err != nil {
+				t.Fatalf("ParseNetwork: %v", err)
+			}
+			if got := n.String(); got != tt.want {
+				t.Errorf("ParseNetwork(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+
+			// The canonical form parses back to the same network
+			again, err := ParseNetwork(n.String())
+			if err != nil || again != n {
+				t.Errorf("ParseNetwork(%q) = %v, %v; want %v", n.String(), again, err, n)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "10.0.0.0/33", "10.0.0.256", "2001:db8::/129", "office", "10.0.0.0/"} {
+		if n, err := ParseNetwork(in); err == nil {
+			t.Errorf("ParseNetwork(%q) = %v, want an error", in, n)
+		}
+	}
+}
+
+func TestNetworkContains(t *testing.T) {
+	v4, _ := ParseNetwork("10.0.0.0/8")
+	v6, _ := ParseNetwork("2001:db8::/32")
+
+	tests := []struct {
+		n    Network
+		addr string
+		want bool
+	}{
+		{v4, "10.0.0.0", true},
+		{v4, "10.255.255.255", true},
+		{v4, "11.0.0.0", false},
+		{v4, "::ffff:10.1.2.3", true},
+		{v4, "::a01:203", false}, // IPv4-compatible, not mapped
+		{v6, "2001:db8:ffff::1", true},
+		{v6, "2001:db8::1%eth0", true},
+		{v6, "10.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.n.Contains(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("%s contains %s = %v, want %v", tt.n, tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestParseSetOrder(t *testing.T) {
+	s, err := ParseSet("x", []string{"2001:db8::/32", "10.1.0.0/16", "10.0.0.0/8", "10.1.2.3/16", "::ffff:10.0.0.0/104"})
+	if err != nil {
+		t.Fatalf("ParseSet: %v", err)
+	}
+	want := "10.0.0.0/8\n10.1.0.0/16\n2001:db8::/32\n"
+	if got := s.String(); got != want {
+		t.Errorf("set = %q, want %q", got, want)
+	}
+}
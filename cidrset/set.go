@@ -0,0 +1,102 @@
+package cidrset
+
+import (
+	"maps"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Set is a named list of networks in canonical order: sorted with IPv4
+// before IPv6 and duplicates removed. Meta carries free-form attributes,
+// such as the settings of a config group.
+type Set struct {
+	Name     string
+	Meta     map[string]string
+	networks []Network
+}
+
+// NewSet returns a set of the given networks in canonical order. Invalid
+// (zero) networks are dropped.
+func NewSet(name string, networks ...Network) *Set {
+	list := make([]Network, 0, len(networks))
+	for _, n := range networks {
+		if n.IsValid() {
+			list = append(list, n)
+		}
+	}
+	slices.SortFunc(list, Network.Compare)
+	list = slices.Compact(list)
+	return &Set{Name: name, Meta: make(map[string]string), networks: list}
+}
+
+// ParseSet parses CIDRs and single addresses into a set.
+func ParseSet(name string, entries []string) (*Set, error) {
+	networks := make([]Network, 0, len(entries))
+	for _, entry := range entries {
+		n, err := ParseNetwork(entry)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, n)
+	}
+	return NewSet(name, networks...), nil
+}
+
+// Networks returns the networks in canonical order. The slice is shared
+// with the set and must not be modified.
+func (s *Set) Networks() []Network {
+	return s.networks
+}
+
+// Len returns the number of networks.
+func (s *Set) Len() int {
+	return len(s.networks)
+}
+
+// Contains reports whether any network in the set contains addr.
+func (s *Set) Contains(addr netip.Addr) bool {
+	for _, n := range s.networks {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether two sets have the same name, attributes and
+// networks.
+func (s *Set) Equal(o *Set) bool {
+	return s.Name == o.Name && maps.Equal(s.Meta, o.Meta) && slices.Equal(s.networks, o.networks)
+}
+
+// String returns the canonical text form: one network per line, each line
+// ending in a newline. Equal network lists always produce the same text.
+func (s *Set) String() string {
+	var b strings.Builder
+	for _, n := range s.networks {
+		b.WriteString(n.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with Marshal.
+func (s *Set) MarshalBinary() ([]byte, error) {
+	return Marshal(s), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data must
+// hold exactly one set.
+func (s *Set) UnmarshalBinary(data []byte) error {
+	sets, err := Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	if len(sets) != 1 {
+		return &FormatError{Msg: "expected 1 set, found " + strconv.Itoa(len(sets))}
+	}
+	*s = *sets[0]
+	return nil
+}
@@ -0,0 +1,97 @@
+package cidrset
+
+import "net/netip"
+
+// Trie indexes networks so that a lookup takes one step per address bit
+// (at most 32 for IPv4, 128 for IPv6) however many networks it holds. Each
+// network carries the values inserted with it. The zero Trie is empty and
+// ready to use.
+type Trie[V any] struct {
+	v4, v6 *trieNode[V]
+	len    int
+}
+
+type trieNode[V any] struct {
+	child  [2]*trieNode[V]
+	values []V
+}
+
+// Insert adds v for network n. Inserting the same network again adds
+// another value rather than replacing the first.
+func (t *Trie[V]) Insert(n Network, v V) {
+	if !n.IsValid() {
+		return
+	}
+	root := &t.v4
+	if n.Addr().Is6() {
+		root = &t.v6
+	}
+	if *root == nil {
+		*root = &trieNode[V]{}
+	}
+
+	node := *root
+	bytes := n.Addr().AsSlice()
+	for i := range n.Bits() {
+		b := bit(bytes, i)
+		if node.child[b] == nil {
+			node.child[b] = &trieNode[V]{}
+		}
+		node = node.child[b]
+	}
+	node.values = append(node.values, v)
+	t.len++
+}
+
+// Len returns the number of values inserted.
+func (t *Trie[V]) Len() int {
+	return t.len
+}
+
+// Lookup returns the values of every network containing addr, from the
+// shortest prefix to the longest. As with Network.Contains, IPv4-mapped
+// IPv6 addresses match IPv4 networks.
+func (t *Trie[V]) Lookup(addr netip.Addr) []V {
+	var values []V
+	t.walk(addr, func(node *trieNode[V]) bool {
+		values = append(values, node.values...)
+		return true
+	})
+	return values
+}
+
+// Contains reports whether any network contains addr.
+func (t *Trie[V]) Contains(addr netip.Addr) bool {
+	found := false
+	t.walk(addr, func(node *trieNode[V]) bool {
+		found = len(node.values) > 0
+		return !found
+	})
+	return found
+}
+
+// walk calls visit for each node on the path of addr until visit returns
+// false or the path ends.
+func (t *Trie[V]) walk(addr netip.Addr, visit func(*trieNode[V]) bool) {
+	if !addr.IsValid() {
+		return
+	}
+	addr = addr.Unmap()
+	node := t.v4
+	if addr.Is6() {
+		node = t.v6
+	}
+
+	bytes := addr.AsSlice()
+	for i := 0; node != nil; i++ {
+		if !visit(node) || i == len(bytes)*8 {
+			return
+		}
+		node = node.child[bit(bytes, i)]
+	}
+}
+
+// bit returns bit i of b, counting from the most significant.
+func bit(b []byte, i int) int {
+	return int(b[i/8]>>(7-i%8)) & 1
+}
@@ -0,0 +1,63 @@
+package cidrset
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	var trie Trie[string]
+	for _, s := range []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.3", "::ffff:192.0.2.0/120", "2001:db8::/32", "2001:db8:1::/48"} {
+		n, err := ParseNetwork(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trie.Insert(n, n.String())
+	}
+	// A second value for an existing network
+	n, _ := ParseNetwork("10.0.0.0/8")
+	trie.Insert(n, "private")
+	trie.Insert(Network{}, "ignored")
+
+	if trie.Len() != 8 {
+		t.Errorf("Len = %d, want 8", trie.Len())
+	}
+
+	tests := []struct {
+		addr string
+		want []string
+	}{
+		{"10.1.2.3", []string{"0.0.0.0/0", "10.0.0.0/8", "private", "10.1.0.0/16", "10.1.2.3/32"}},
+		{"10.1.2.4", []string{"0.0.0.0/0", "10.0.0.0/8", "private", "10.1.0.0/16"}},
+		{"10.0.0.0", []string{"0.0.0.0/0", "10.0.0.0/8", "private"}},
+		{"10.255.255.255", []string{"0.0.0.0/0", "10.0.0.0/8", "private"}},
+		{"::ffff:10.1.2.3", []string{"0.0.0.0/0", "10.0.0.0/8", "private", "10.1.0.0/16", "10.1.2.3/32"}},
+		{"192.0.2.255", []string{"0.0.0.0/0", "192.0.2.0/24"}},
+		{"11.0.0.1", []string{"0.0.0.0/0"}},
+		{"2001:db8:1::1", []string{"2001:db8::/32", "2001:db8:1::/48"}},
+		{"2001:db8:2::1%eth0", []string{"2001:db8::/32"}},
+		{"2001:db9::1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			addr := netip.MustParseAddr(tt.addr)
+			if got := trie.Lookup(addr); !slices.Equal(got, tt.want) {
+				t.Errorf("Lookup = %v, want %v", got, tt.want)
+			}
+			if got := trie.Contains(addr); got != (len(tt.want) > 0) {
+				t.Errorf("Contains = %v, want %v", got, len(tt.want) > 0)
+			}
+		})
+	}
+
+	if got := trie.Lookup(netip.Addr{}); got != nil {
+		t.Errorf("Lookup of the zero Addr = %v, want nil", got)
+	}
+
+	var empty Trie[int]
+	if empty.Contains(netip.MustParseAddr("10.0.0.1")) || empty.Len() != 0 {
+		t.Errorf("zero Trie is not empty")
+	}
+}
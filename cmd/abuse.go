@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	abuseRDAPURL string
+	abuseOutput  string
+	abuseDelay   time.Duration
+)
+
+var abuseCmd = &cobra.Command{
+	Use:   "abuse-contacts [IP list file]",
+	Short: "Export abuse contacts for a list of IPs as CSV",
+	Long: titleStyle.Render("Abuse Contact Export") + "\n\n" +
+		"Look up the registry netblock for each IP address using RDAP.\n" +
+		"Addresses covered by a netblock that was already fetched are not looked up again.\n" +
+		"Emits one CSV row per netblock with its abuse email and the IPs it covers.\n" +
+		"Reads the list from stdin when the file is '-'.",
+	Example: `  cidr abuse-contacts ips.txt
+  cidr abuse-contacts ips.txt --output complaints.csv
+  grep 'Failed password' auth.log | awk '{print $11}' | cidr abuse-contacts -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAbuseContacts,
+}
+
+func init() {
+	abuseCmd.Flags().StringVar(&abuseRDAPURL, "rdap", "https://rdap.org", "Base URL of the RDAP bootstrap service")
+	abuseCmd.Flags().StringVarP(&abuseOutput, "output", "o", "", "Write the CSV to a file instead of stdout")
+	abuseCmd.Flags().DurationVar(&abuseDelay, "delay", 500*time.Millisecond, "Pause between RDAP requests to respect registry rate limits")
+	rootCmd.AddCommand(abuseCmd)
+}
+
+// abuseNetblock is a registry netblock and the input IPs it covers.
+type abuseNetblock struct {
+	Start  net.IP
+	End    net.IP
+	CIDRs  []string
+	Name   string
+	Handle string
+	Emails []string
+	IPs    []net.IP
+}
+
+func (n *abuseNetblock) contains(ip net.IP) bool {
+	return compareIPs(n.Start, ip) <= 0 && compareIPs(ip, n.End) <= 0
+}
+
+// rdapNetwork holds the parts of an RDAP ip network response we need.
+type rdapNetwork struct {
+	StartAddress string       `json:"startAddress"`
+	EndAddress   string       `json:"endAddress"`
+	Name         string       `json:"name"`
+	Handle       string       `json:"handle"`
+	CIDRs        []rdapCIDR   `json:"cidr0_cidrs"`
+	Entities     []rdapEntity `json:"entities"`
+}
+
+type rdapCIDR struct {
+	V4Prefix string `json:"v4prefix"`
+	V6Prefix string `json:"v6prefix"`
+	Length   int    `json:"length"`
+}
+
+type rdapEntity struct {
+	Roles      []string        `json:"roles"`
+	VCardArray json.RawMessage `json:"vcardArray"`
+	Entities   []rdapEntity    `json:"entities"`
+}
+
+func runAbuseContacts(cmd *cobra.Command, args []string) error {
+	lines, err := readListFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	var ips []net.IP
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		ip := net.ParseIP(line)
+		if ip == nil {
+			return fmt.Errorf("invalid IP address: %s", line)
+		}
+		if seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool { return compareIPs(ips[i], ips[j]) < 0 })
+
+	cfg, err := loadConfig()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	timeout, err := sourceTimeout(cfg, "rdap")
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: timeout}
+
+	var netblocks []*abuseNetblock
+	var failed []string
+	lookups := 0
+	for _, ip := range ips {
+		var block *abuseNetblock
+		for _, n := range netblocks {
+			if n.contains(ip) {
+				block = n
+				break
+			}
+		}
+
+		if block == nil {
+			if lookups > 0 && abuseDelay > 0 {
+				time.Sleep(abuseDelay)
+			}
+			lookups++
+			block, err = lookupAbuseNetblock(client, ip)
+			if err != nil {
+				err = timeoutError("rdap", timeout, err)
+				fmt.Fprintf(os.Stderr, "%s %s: %v\n", errorStyle.Render("✗"), ip, err)
+				failed = append(failed, ip.String())
+				continue
+			}
+			netblocks = append(netblocks, block)
+		}
+
+		block.IPs = append(block.IPs, ip)
+	}
+
+	out := os.Stdout
+	if abuseOutput != "" {
+		f, err := os.Create(abuseOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := writeAbuseCSV(out, netblocks); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, dimStyle.Render(fmt.Sprintf("%d IPs, %d netblocks, %d RDAP lookups", len(ips), len(netblocks), lookups)))
+	if len(failed) > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("RDAP lookup failed for %d IP(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func lookupAbuseNetblock(client *http.Client, ip net.IP) (*abuseNetblock, error) {
+	url := strings.TrimSuffix(abuseRDAPURL, "/") + "/ip/" + ip.String()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("RDAP server returned %s", resp.Status)
+	}
+
+	var network rdapNetwork
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&network); err != nil {
+		return nil, fmt.Errorf("invalid RDAP response: %w", err)
+	}
+
+	start := net.ParseIP(network.StartAddress)
+	end := net.ParseIP(network.EndAddress)
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("RDAP response has no address range")
+	}
+
+	block := &abuseNetblock{
+		Start:  start,
+		End:    end,
+		Name:   network.Name,
+		Handle: network.Handle,
+		Emails: abuseEmails(network.Entities),
+	}
+
+	for _, c := range network.CIDRs {
+		prefix := c.V4Prefix
+		if prefix == "" {
+			prefix = c.V6Prefix
+		}
+		block.CIDRs = append(block.CIDRs, prefix+"/"+strconv.Itoa(c.Length))
+	}
+	if len(block.CIDRs) == 0 {
+		for _, ipnet := range rangeToCIDRs(start, end) {
+			block.CIDRs = append(block.CIDRs, ipnet.String())
+		}
+	}
+
+	return block, nil
+}
+
+// abuseEmails collects the email addresses of every entity with the abuse
+// role, including entities nested inside other entities as ARIN returns them.
+func abuseEmails(entities []rdapEntity) []string {
+	var emails []string
+	for _, entity := range entities {
+		for _, role := range entity.Roles {
+			if role == "abuse" {
+				emails = append(emails, vcardEmails(entity.VCardArray)...)
+				break
+			}
+		}
+		emails = append(emails, abuseEmails(entity.Entities)...)
+	}
+
+	// Deduplicate while preserving order
+	seen := make(map[string]bool)
+	var unique []string
+	for _, email := range emails {
+		email = strings.ToLower(email)
+		if !seen[email] {
+			seen[email] = true
+			unique = append(unique, email)
+		}
+	}
+	return unique
+}
+
+// vcardEmails extracts email properties from a jCard array of the form
+// ["vcard", [["email", {}, "text", "abuse@example.net"], ...]].
+func vcardEmails(raw json.RawMessage) []string {
+	var vcard []json.RawMessage
+	if err := json.Unmarshal(raw, &vcard); err != nil || len(vcard) < 2 {
+		return nil
+	}
+
+	var properties [][]json.RawMessage
+	if err := json.Unmarshal(vcard[1], &properties); err != nil {
+		return nil
+	}
+
+	var emails []string
+	for _, property := range properties {
+		if len(property) < 4 {
+			continue
+		}
+		var name, value string
+		if json.Unmarshal(property[0], &name) != nil || name != "email" {
+			continue
+		}
+		if json.Unmarshal(property[3], &value) == nil && value != "" {
+			emails = append(emails, value)
+		}
+	}
+	return emails
+}
+
+func writeAbuseCSV(w io.Writer, netblocks []*abuseNetblock) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"prefix", "netblock", "handle", "abuse_email", "ip_count", "ips"}); err != nil {
+		return err
+	}
+
+	for _, block := range netblocks {
+		ips := make([]string, len(block.IPs))
+		for i, ip := range block.IPs {
+			ips[i] = ip.String()
+		}
+		record := []string{
+			strings.Join(block.CIDRs, " "),
+			block.Name,
+			block.Handle,
+			strings.Join(block.Emails, " "),
+			strconv.Itoa(len(block.IPs)),
+			strings.Join(ips, " "),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+
+	"github.com/spf13/cobra"
+)
+
+var alignedCmd = &cobra.Command{
+	Use:   "aligned [CIDR...]",
+	Short: "Check that CIDRs start on their natural boundary",
+	Long: titleStyle.Render("Alignment Check") + "\n\n" +
+		"Report CIDRs whose address is not the network address for their prefix length,\n" +
+		"such as 10.0.3.0/23, and show the valid networks around them.\n" +
+		"Checks the config file ranges when no CIDR is given.\n\n" +
+		"Exits non-zero when any CIDR is misaligned.",
+	Example: `  cidr aligned 10.0.3.0/23
+  cidr aligned --group office`,
+	RunE: runAligned,
+}
+
+func init() {
+	rootCmd.AddCommand(alignedCmd)
+}
+
+func runAligned(cmd *cobra.Command, args []string) error {
+	cidrs := args
+	if len(cidrs) == 0 {
+		configCIDRs, path, err := loadConfigCIDRs()
+		if err != nil {
+			return fmt.Errorf("no CIDR provided and could not load config file: %w", err)
+		}
+		cidrs = configCIDRs
+		fmt.Println(dimStyle.Render(fmt.Sprintf("Using config from: %s", path)))
+		fmt.Println()
+	}
+
+	fmt.Println(titleStyle.Render("Alignment Check"))
+
+	misaligned := 0
+	for _, cidrStr := range cidrs {
+		ip, ipnet, err := net.ParseCIDR(cidrStr)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR notation '%s': %w", cidrStr, err)
+		}
+
+		if ip.Equal(ipnet.IP) {
+			fmt.Printf("%s %s\n", successStyle.Render("✓"), valueStyle.Render(cidrStr))
+			continue
+		}
+
+		misaligned++
+		ones, _ := ipnet.Mask.Size()
+		fmt.Printf("%s %s %s\n", errorStyle.Render("✗"), valueStyle.Render(cidrStr),
+			errorStyle.Render(fmt.Sprintf("%s is not on a /%d boundary", ip, ones)))
+
+		for _, candidate := range surroundingNetworks(ipnet) {
+			note := ""
+			if candidate.Contains(ip) {
+				note = dimStyle.Render(fmt.Sprintf(" (contains %s)", ip))
+			}
+			fmt.Printf("    %s %s%s\n", infoStyle.Render("→"), candidate.String(), note)
+		}
+	}
+
+	fmt.Println()
+	if misaligned == 0 {
+		fmt.Println(successStyle.Render("All CIDRs are aligned"))
+		return nil
+	}
+
+	fmt.Println(errorStyle.Render(fmt.Sprintf("%d of %d CIDR(s) are not aligned", misaligned, len(cidrs))))
+	cmd.SilenceUsage = true
+	return fmt.Errorf("%d misaligned CIDR(s)", misaligned)
+}
+
+// surroundingNetworks returns the aligned network that contains the
+// misaligned address and its neighbour above, or below when the network is
+// the last one in the address space.
+func surroundingNetworks(ipnet *net.IPNet) []*net.IPNet {
+	if next := offsetNetwork(ipnet, 1); next != nil {
+		return []*net.IPNet{ipnet, next}
+	}
+	if prev := offsetNetwork(ipnet, -1); prev != nil {
+		return []*net.IPNet{prev, ipnet}
+	}
+	return []*net.IPNet{ipnet}
+}
+
+// offsetNetwork returns the network delta blocks away from ipnet with the
+// same prefix length, or nil when that falls outside the address space.
+func offsetNetwork(ipnet *net.IPNet, delta int64) *net.IPNet {
+	ones, bits := ipnet.Mask.Size()
+	base := ipnet.IP.To4()
+	if bits == 128 {
+		base = ipnet.IP.To16()
+	}
+
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	n := new(big.Int).SetBytes(base)
+	n.Add(n, size.Mul(size, big.NewInt(delta)))
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	if n.Sign() < 0 || n.Cmp(limit) >= 0 {
+		return nil
+	}
+
+	ip := make(net.IP, len(base))
+	n.FillBytes(ip)
+	return &net.IPNet{IP: ip, Mask: ipnet.Mask}
+}
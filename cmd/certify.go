@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	certifySignKey string
+	certifyOutput  string
+)
+
+var certifyCmd = &cobra.Command{
+	Use:   "certify [IP]",
+	Short: "Produce a hash-stamped statement that an IP was screened against a list",
+	Long: titleStyle.Render("Screening Certificate") + "\n\n" +
+		"Check an IP against a list (a config group, service tag or the whole config)\n" +
+		"and emit a JSON statement with the IP, the list's SHA-256 and the verdict.\n" +
+		"The statement is stamped with its own SHA-256 and optionally signed with an\n" +
+		"Ed25519 key, so it can be attached to compliance tickets.\n\n" +
+		"Exits non-zero when the IP is listed.",
+	Example: `  cidr certify 203.0.113.7 --group deny
+  cidr certify 203.0.113.7 --group deny --sign-key screening.pem --output ticket-1234.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCertify,
+}
+
+func init() {
+	certifyCmd.Flags().StringVar(&certifySignKey, "sign-key", "", "PEM-encoded Ed25519 private key (PKCS #8) to sign the statement")
+	certifyCmd.Flags().StringVarP(&certifyOutput, "output", "o", "", "Write the statement to a file instead of stdout")
+	rootCmd.AddCommand(certifyCmd)
+}
+
+// screeningStatement is the signed part of a certificate. Field order is
+// fixed so the encoding is reproducible.
+type screeningStatement struct {
+	Version   int           `json:"version"`
+	IP        string        `json:"ip"`
+	List      screeningList `json:"list"`
+	Verdict   string        `json:"verdict"`
+	Matches   []string      `json:"matches"`
+	CheckedAt string        `json:"checked_at"`
+}
+
+type screeningList struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	SHA256  string `json:"sha256"`
+	Entries int    `json:"entries"`
+}
+
+type screeningCertificate struct {
+	Statement screeningStatement  `json:"statement"`
+	SHA256    string              `json:"sha256"`
+	Signature *screeningSignature `json:"signature,omitempty"`
+}
+
+type screeningSignature struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+	Value     string `json:"value"`
+}
+
+func runCertify(cmd *cobra.Command, args []string) error {
+	ip := net.ParseIP(args[0])
+	if ip == nil {
+		return fmt.Errorf("invalid IP address: %s", args[0])
+	}
+
+	cidrs, source, err := loadConfigCIDRs()
+	if err != nil {
+		return fmt.Errorf("could not load list: %w", err)
+	}
+
+	list, err := canonicalCIDRList(cidrs)
+	if err != nil {
+		return err
+	}
+
+	var matches []string
+	for _, ipnet := range list {
+		if ipnet.Contains(ip) {
+			matches = append(matches, ipnet.String())
+		}
+	}
+
+	name := configGroup
+	if name == "" {
+		name = "all"
+	}
+	statement := screeningStatement{
+		Version: 1,
+		IP:      ip.String(),
+		List: screeningList{
+			Name:    name,
+			Source:  source,
+			SHA256:  hashCIDRList(list),
+			Entries: len(list),
+		},
+		Verdict:   "not-listed",
+		Matches:   []string{},
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if len(matches) > 0 {
+		statement.Verdict = "listed"
+		statement.Matches = matches
+	}
+
+	payload, err := marshalCanonical(statement)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(payload)
+
+	cert := screeningCertificate{
+		Statement: statement,
+		SHA256:    hex.EncodeToString(digest[:]),
+	}
+
+	if certifySignKey != "" {
+		key, err := loadEd25519Key(certifySignKey)
+		if err != nil {
+			return err
+		}
+		cert.Signature = &screeningSignature{
+			Algorithm: "ed25519",
+			PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+			Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+		}
+	}
+
+	out, err := json.MarshalIndent(cert, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+
+	if certifyOutput == "" {
+		os.Stdout.Write(out)
+	} else {
+		if err := os.WriteFile(certifyOutput, out, 0o644); err != nil {
+			return err
+		}
+		fmt.Println(titleStyle.Render("Screening Certificate"))
+		fmt.Printf("%s %s\n", labelStyle.Render("IP:"), valueStyle.Render(statement.IP))
+		fmt.Printf("%s %s %s\n", labelStyle.Render("List:"), valueStyle.Render(statement.List.Name),
+			dimStyle.Render("sha256:"+statement.List.SHA256))
+		fmt.Printf("%s %s\n", labelStyle.Render("Verdict:"), valueStyle.Render(statement.Verdict))
+		fmt.Printf("%s %s\n", labelStyle.Render("Written to:"), valueStyle.Render(certifyOutput))
+	}
+
+	if statement.Verdict == "listed" {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%s is listed in %s (%s)", statement.IP, statement.List.Name, strings.Join(matches, ", "))
+	}
+
+	return nil
+}
+
+// canonicalCIDRList parses, masks, sorts and deduplicates a list of CIDRs so
+// that equivalent lists always hash the same.
+func canonicalCIDRList(cidrs []string) ([]*net.IPNet, error) {
+	seen := make(map[string]bool)
+	var list []*net.IPNet
+	for _, cidrStr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidrStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR notation '%s': %w", cidrStr, err)
+		}
+		if seen[ipnet.String()] {
+			continue
+		}
+		seen[ipnet.String()] = true
+		list = append(list, ipnet)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if c := compareIPs(list[i].IP, list[j].IP); c != 0 {
+			return c < 0
+		}
+		oi, _ := list[i].Mask.Size()
+		oj, _ := list[j].Mask.Size()
+		return oi < oj
+	})
+
+	return list, nil
+}
+
+// hashCIDRList returns the SHA-256 of the list written one CIDR per line.
+func hashCIDRList(list []*net.IPNet) string {
+	h := sha256.New()
+	for _, ipnet := range list {
+		fmt.Fprintln(h, ipnet.String())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// marshalCanonical encodes v as compact JSON without HTML escaping, which is
+// what `jq -cj` prints, so statements can be re-hashed with standard tools.
+func marshalCanonical(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func loadEd25519Key(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 private key", path)
+	}
+	return edKey, nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"net"
+)
+
+// specialRange is an IANA special-purpose block and the class reported for
+// addresses inside it.
+type specialRange struct {
+	Net   *net.IPNet
+	Class string
+}
+
+// specialRanges lists special-purpose address blocks (RFC 6890 and the IANA
+// registries), most specific first so the first match wins.
+var specialRanges = mustSpecialRanges([][2]string{
+	{"255.255.255.255/32", "broadcast"},
+	{"0.0.0.0/32", "unspecified"},
+	{"0.0.0.0/8", "reserved"},
+	{"10.0.0.0/8", "private"},
+	{"100.64.0.0/10", "shared"},
+	{"127.0.0.0/8", "loopback"},
+	{"169.254.0.0/16", "link-local"},
+	{"172.16.0.0/12", "private"},
+	{"192.0.0.0/24", "reserved"},
+	{"192.0.2.0/24", "documentation"},
+	{"192.88.99.0/24", "reserved"},
+	{"192.168.0.0/16", "private"},
+	{"198.18.0.0/15", "benchmarking"},
+	{"198.51.100.0/24", "documentation"},
+	{"203.0.113.0/24", "documentation"},
+	{"224.0.0.0/4", "multicast"},
+	{"240.0.0.0/4", "reserved"},
+	{"::/128", "unspecified"},
+	{"::1/128", "loopback"},
+	{"64:ff9b::/96", "nat64"},
+	{"64:ff9b:1::/48", "nat64"},
+	{"100::/64", "discard"},
+	{"2001::/32", "teredo"},
+	{"2001:2::/48", "benchmarking"},
+	{"2001:db8::/32", "documentation"},
+	{"2001::/23", "reserved"},
+	{"2002::/16", "6to4"},
+	{"3fff::/20", "documentation"},
+	{"fc00::/7", "private"},
+	{"fe80::/10", "link-local"},
+	{"ff00::/8", "multicast"},
+})
+
+func mustSpecialRanges(entries [][2]string) []specialRange {
+	ranges := make([]specialRange, len(entries))
+	for i, entry := range entries {
+		_, ipnet, err := net.ParseCIDR(entry[0])
+		if err != nil {
+			panic(err)
+		}
+		ranges[i] = specialRange{Net: ipnet, Class: entry[1]}
+	}
+	return ranges
+}
+
+// classifyIP returns the special-purpose class of an address, or "global"
+// for ordinary public unicast space.
+func classifyIP(ip net.IP) string {
+	// IPv4-mapped addresses are classified by their embedded IPv4 address
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, r := range specialRanges {
+		if len(r.Net.IP) == len(ip) && r.Net.Contains(ip) {
+			return r.Class
+		}
+	}
+	return "global"
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/trahma/cidr/cidrset"
+)
+
+var (
+	compileOutput  string
+	compileInspect string
+)
+
+var compileCmd = &cobra.Command{
+	Use:   "compile [CIDR...]",
+	Short: "Compile range sets to a binary file for other services",
+	Long: titleStyle.Render("Compile Range Sets") + "\n\n" +
+		"Encode ranges in the versioned cidrset binary format (MessagePack), so services\n" +
+		"using the github.com/trahma/cidr/cidrset package can load them without\n" +
+		"reparsing text. Uses the CIDRs given as arguments, the --group, or every group\n" +
+		"in the config, one set per group with the group's settings.\n\n" +
+		"Networks are stored in canonical order, so the same ranges always compile to\n" +
+		"the same bytes. Use --inspect to show a compiled file.",
+	Example: `  cidr compile -o ranges.cidrset
+  cidr compile --group office -o - | ssh edge 'cat > /etc/app/office.cidrset'
+  cidr compile --inspect ranges.cidrset`,
+	RunE: runCompile,
+}
+
+func init() {
+	compileCmd.Flags().StringVarP(&compileOutput, "output", "o", "", "File to write the compiled sets to ('-' for stdout)")
+	compileCmd.Flags().StringVar(&compileInspect, "inspect", "", "Show the contents of a compiled file instead of compiling")
+	rootCmd.AddCommand(compileCmd)
+}
+
+func runCompile(cmd *cobra.Command, args []string) error {
+	if compileInspect != "" {
+		return inspectCompiled(compileInspect)
+	}
+	if compileOutput == "" {
+		return fmt.Errorf("use --output FILE (or - for stdout) to choose where to write the compiled sets")
+	}
+
+	exportSets, err := collectExportSets(args)
+	if err != nil {
+		return err
+	}
+
+	sets := make([]*cidrset.Set, len(exportSets))
+	networks := 0
+	for i, es := range exportSets {
+		set, err := toCIDRSet(es)
+		if err != nil {
+			return err
+		}
+		sets[i] = set
+		networks += set.Len()
+	}
+
+	data := cidrset.Marshal(sets...)
+
+	summary := fmt.Sprintf("%d set(s), %d networks, %d bytes (cidrset v%d)", len(sets), networks, len(data), cidrset.WireVersion)
+	if compileOutput == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, dimStyle.Render(summary))
+		return nil
+	}
+
+	if err := os.WriteFile(compileOutput, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s %s\n", successStyle.Render("✓"), valueStyle.Render(compileOutput), dimStyle.Render(summary))
+
+	return nil
+}
+
+// toCIDRSet converts an export set into a library set with its settings.
+func toCIDRSet(es exportSet) (*cidrset.Set, error) {
+	entries := make([]string, len(es.CIDRs))
+	for i, ipnet := range es.CIDRs {
+		entries[i] = ipnet.String()
+	}
+	set, err := cidrset.ParseSet(es.Name, entries)
+	if err != nil {
+		return nil, fmt.Errorf("set '%s': %w", es.Name, err)
+	}
+	for key, value := range es.Meta {
+		set.Meta[key] = value
+	}
+	return set, nil
+}
+
+func inspectCompiled(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sets, err := cidrset.Unmarshal(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	fmt.Println(titleStyle.Render("Compiled Range Sets"))
+	fmt.Printf("%s %s\n", labelStyle.Render("File:"), valueStyle.Render(path))
+	fmt.Printf("%s %s\n", labelStyle.Render("Size:"), valueStyle.Render(fmt.Sprintf("%d bytes", len(data))))
+	fmt.Printf("%s %s\n", labelStyle.Render("Sets:"), valueStyle.Render(fmt.Sprintf("%d", len(sets))))
+
+	for _, set := range sets {
+		fmt.Println()
+		fmt.Printf("%s %s\n", labelStyle.Render(set.Name+":"), dimStyle.Render(fmt.Sprintf("%d networks", set.Len())))
+		for _, key := range slices.Sorted(maps.Keys(set.Meta)) {
+			fmt.Printf("  %s %s\n", dimStyle.Render(key+" ="), set.Meta[key])
+		}
+		for _, n := range set.Networks() {
+			fmt.Printf("  %s\n", valueStyle.Render(n.String()))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr compile --help' for more options"))
+
+	return nil
+}
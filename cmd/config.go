@@ -0,0 +1,448 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Config files are loaded in this order; later files add ranges and
+// override settings from earlier ones, like git's system/global/local config.
+var systemConfigPath = "/etc/cidr/config"
+
+// configLayer is one config file in the cascade.
+type configLayer struct {
+	Scope  string
+	Path   string
+	Loaded bool
+}
+
+// cidrConfig is the merged result of all config files. Ranges listed before
+// any section are ungrouped; ranges under a [group "name"] section belong to
+// that group. Settings are stored git-style as "section.subsection.key".
+type cidrConfig struct {
+	Path    string
+	Layers  []configLayer
+	Entries []configEntry
+	Groups  []string
+	Values  map[string]string
+	Origins map[string]string
+
+	// ExcludeDeprecated leaves deprecated entries out of resolved ranges
+	ExcludeDeprecated bool
+}
+
+// configEntry is a single range line. Value is either a CIDR or a symbolic
+// name such as an Azure service tag that is resolved when the group is used.
+// Deprecated entries may name a Replacement; they still match but produce
+// warnings, and exporters can leave them out.
+type configEntry struct {
+	Value       string
+	Group       string
+	Deprecated  bool
+	Replacement string
+	Source      string
+	Line        int
+}
+
+var (
+	sectionHeaderPattern = regexp.MustCompile(`^\[\s*([A-Za-z][A-Za-z0-9-]*)(?:\s+"([^"]*)")?\s*\]$`)
+	configKeyPattern     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the config files",
+	Long: titleStyle.Render("Configuration") + "\n\n" +
+		"Config files are merged in this order, later files taking precedence:\n" +
+		"  system   " + systemConfigPath + "\n" +
+		"  user     ~/.cidr\n" +
+		"  project  ./.cidr\n\n" +
+		"Ranges from all files are combined (groups with the same name are merged)\n" +
+		"and settings in later files override earlier ones. --config uses only the\n" +
+		"given file.",
+}
+
+var configSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Show which config files were loaded and where each value came from",
+	Example: `  cidr config sources
+  cidr config sources --config ./ranges.cidr`,
+	Args: cobra.NoArgs,
+	RunE: runConfigSources,
+}
+
+func init() {
+	configCmd.AddCommand(configSourcesCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigSources(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	layers := []configLayer(nil)
+	if cfg != nil {
+		layers = cfg.Layers
+	} else if layers, err = configLayers(); err != nil {
+		return err
+	}
+
+	fmt.Println(titleStyle.Render("Config Sources"))
+	for _, layer := range layers {
+		if layer.Loaded {
+			fmt.Printf("%s %s %s\n", successStyle.Render("✓"), labelStyle.Render(fmt.Sprintf("%-8s", layer.Scope)), valueStyle.Render(layer.Path))
+		} else {
+			fmt.Printf("%s %s %s\n", infoStyle.Render("○"), labelStyle.Render(fmt.Sprintf("%-8s", layer.Scope)), dimStyle.Render(layer.Path+" (not found)"))
+		}
+	}
+
+	if cfg == nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("No config file found"))
+		return nil
+	}
+
+	if len(cfg.Values) > 0 {
+		fmt.Println()
+		fmt.Println(titleStyle.Render("Settings"))
+		keys := make([]string, 0, len(cfg.Values))
+		for key := range cfg.Values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s %s %s\n", labelStyle.Render(key+" ="), valueStyle.Render(cfg.Values[key]), dimStyle.Render(cfg.Origins[key]))
+		}
+	}
+
+	if len(cfg.Entries) > 0 {
+		fmt.Println()
+		fmt.Println(titleStyle.Render("Ranges"))
+		for _, entry := range cfg.Entries {
+			group := "(ungrouped)"
+			if entry.Group != "" {
+				group = entry.Group
+			}
+			status := ""
+			if entry.Deprecated {
+				status = infoStyle.Render(" deprecated")
+				if entry.Replacement != "" {
+					status += infoStyle.Render(" → " + entry.Replacement)
+				}
+			}
+			fmt.Printf("%s %s%s %s\n", valueStyle.Render(entry.Value), labelStyle.Render(group), status, dimStyle.Render(fmt.Sprintf("%s:%d", entry.Source, entry.Line)))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr config --help' for more options"))
+
+	return nil
+}
+
+// loadConfigCIDRs loads the config files and returns their ranges with
+// symbolic names resolved. When --group is set only that group's ranges are
+// returned; a group that is not defined in the config may still name a
+// service tag or prefix list.
+func loadConfigCIDRs() ([]string, string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		if symbolicGroup() {
+			return resolveName(nil, configGroup)
+		}
+		return nil, "", err
+	}
+	return configCIDRs(cfg)
+}
+
+// symbolicGroup reports whether --group names a service tag or prefix list,
+// which resolves without a config file.
+func symbolicGroup() bool {
+	return configGroup != "" && isSymbolicName(nil, configGroup)
+}
+
+// configCIDRs returns the ranges of a loaded config and its path, limited
+// to --group when it is set.
+func configCIDRs(cfg *cidrConfig) ([]string, string, error) {
+	cidrs, err := cfg.groupCIDRs(configGroup)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cidrs, cfg.Path, nil
+}
+
+// configLayers returns the config files to load in precedence order. A
+// --config flag replaces the cascade with that single file.
+func configLayers() ([]configLayer, error) {
+	if configFile != "" {
+		return []configLayer{{Scope: "command", Path: configFile}}, nil
+	}
+
+	layers := []configLayer{{Scope: "system", Path: systemConfigPath}}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, configLayer{Scope: "user", Path: filepath.Join(home, ".cidr")})
+
+	// Skip the project file when running from the home directory, where it
+	// is the user file
+	project, err := filepath.Abs(".cidr")
+	if err != nil {
+		return nil, err
+	}
+	if project != layers[1].Path {
+		layers = append(layers, configLayer{Scope: "project", Path: project})
+	}
+
+	return layers, nil
+}
+
+// loadConfig loads and merges every config file that exists. It returns an
+// error wrapping fs.ErrNotExist when there is none.
+func loadConfig() (*cidrConfig, error) {
+	layers, err := configLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &cidrConfig{
+		Values:            make(map[string]string),
+		Origins:           make(map[string]string),
+		ExcludeDeprecated: excludeDeprecated,
+	}
+	var loaded, searched []string
+	for i, layer := range layers {
+		searched = append(searched, layer.Path)
+		data, err := os.ReadFile(layer.Path)
+		if errors.Is(err, fs.ErrNotExist) && configFile == "" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cfg.parse(string(data), layer.Path); err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.Path, err)
+		}
+		layers[i].Loaded = true
+		loaded = append(loaded, layer.Path)
+	}
+	cfg.Layers = layers
+
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("no config file found (looked for %s): %w", strings.Join(searched, ", "), fs.ErrNotExist)
+	}
+	cfg.Path = strings.Join(loaded, ", ")
+
+	return cfg, nil
+}
+
+// parse merges one config file into the config.
+func (c *cidrConfig) parse(data, source string) error {
+	section := ""
+	group := ""
+
+	for i, line := range strings.Split(data, "\n") {
+		lineNum := i + 1
+		line = strings.TrimSpace(line)
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			match := sectionHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				return fmt.Errorf("line %d: invalid section header '%s'", lineNum, line)
+			}
+			name, sub := strings.ToLower(match[1]), match[2]
+			section, group = name, ""
+			if sub != "" {
+				section += "." + sub
+			}
+			if name == "group" {
+				if sub == "" {
+					return fmt.Errorf("line %d: group section needs a name, e.g. [group \"office\"]", lineNum)
+				}
+				group = sub
+				c.addGroup(sub)
+			}
+			continue
+		}
+
+		if key, value, ok := parseConfigValue(line); ok {
+			if section == "" {
+				return fmt.Errorf("line %d: setting '%s' outside a section", lineNum, key)
+			}
+			c.Values[section+"."+key] = value
+			c.Origins[section+"."+key] = fmt.Sprintf("%s:%d", source, lineNum)
+			continue
+		}
+
+		if section != "" && group == "" {
+			return fmt.Errorf("line %d: range '%s' must be in a group section", lineNum, line)
+		}
+
+		// A range may be followed by attributes:
+		//   10.0.0.0/8 deprecated replacement=10.64.0.0/10
+		fields := strings.Fields(line)
+		entry := configEntry{Value: fields[0], Group: group, Source: source, Line: lineNum}
+		for _, attr := range fields[1:] {
+			key, value, _ := strings.Cut(attr, "=")
+			switch strings.ToLower(key) {
+			case "deprecated":
+				entry.Deprecated = true
+			case "replacement":
+				entry.Deprecated = true
+				entry.Replacement = value
+			default:
+				return fmt.Errorf("line %d: unknown range attribute '%s'", lineNum, attr)
+			}
+		}
+		c.Entries = append(c.Entries, entry)
+	}
+
+	return nil
+}
+
+// parseConfigValue splits a "key = value" line. Range lines never contain a
+// bare key before an equals sign, so they are left alone.
+func parseConfigValue(line string) (string, string, bool) {
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	if !configKeyPattern.MatchString(key) {
+		return "", "", false
+	}
+	return strings.ToLower(key), strings.Trim(strings.TrimSpace(value), `"`), true
+}
+
+func (c *cidrConfig) addGroup(name string) {
+	for _, g := range c.Groups {
+		if g == name {
+			return
+		}
+	}
+	c.Groups = append(c.Groups, name)
+}
+
+func (c *cidrConfig) hasGroup(name string) bool {
+	for _, g := range c.Groups {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns a setting or an empty string when it is not set.
+func (c *cidrConfig) value(key string) string {
+	if c == nil {
+		return ""
+	}
+	return c.Values[key]
+}
+
+// origin returns the file and line a setting came from.
+func (c *cidrConfig) origin(key string) string {
+	if origin, ok := c.Origins[key]; ok {
+		return origin
+	}
+	return c.Path
+}
+
+// groupCIDRs returns the ranges of a group with symbolic names resolved. An
+// empty group name returns every range in the config.
+func (c *cidrConfig) groupCIDRs(group string) ([]string, error) {
+	if group != "" && !c.hasGroup(group) {
+		if isSymbolicName(c, group) {
+			cidrs, _, err := resolveName(c, group)
+			return cidrs, err
+		}
+		return nil, unknownNameError(c, group)
+	}
+
+	return c.resolveEntries(func(entry configEntry) bool {
+		return group == "" || entry.Group == group
+	})
+}
+
+// resolveEntries returns the ranges of the entries selected by keep, with
+// symbolic names resolved.
+func (c *cidrConfig) resolveEntries(keep func(configEntry) bool) ([]string, error) {
+	var cidrs []string
+	for _, entry := range c.Entries {
+		if !keep(entry) || (entry.Deprecated && c.ExcludeDeprecated) {
+			continue
+		}
+		if !isSymbolicName(c, entry.Value) {
+			cidrs = append(cidrs, entry.Value)
+			continue
+		}
+		resolved, _, err := resolveName(c, entry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", entry.Source, entry.Line, err)
+		}
+		cidrs = append(cidrs, resolved...)
+	}
+
+	return cidrs, nil
+}
+
+// deprecations maps each deprecated range, in canonical form, to its
+// replacement (empty when none is given). Deprecated symbolic names mark
+// every prefix they resolve to.
+func (c *cidrConfig) deprecations() (map[string]string, error) {
+	deprecated := make(map[string]string)
+	for _, entry := range c.Entries {
+		if !entry.Deprecated {
+			continue
+		}
+
+		cidrs := []string{entry.Value}
+		if isSymbolicName(c, entry.Value) {
+			resolved, _, err := resolveName(c, entry.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", entry.Source, entry.Line, err)
+			}
+			cidrs = resolved
+		}
+
+		for _, cidrStr := range cidrs {
+			_, ipnet, err := net.ParseCIDR(cidrStr)
+			if err != nil {
+				continue
+			}
+			deprecated[ipnet.String()] = entry.Replacement
+		}
+	}
+	return deprecated, nil
+}
+
+// loadDeprecations returns the deprecated ranges of the config, or nil when
+// there is no config.
+func loadDeprecations() (map[string]string, error) {
+	cfg, err := loadConfig()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg.deprecations()
+}
@@ -0,0 +1,522 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a config file for a test and returns its path.
+func writeConfig(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseConfigValue(t *testing.T) {
+	tests := []struct {
+		line      string
+		key       string
+		value     string
+		isSetting bool
+	}{
+		{line: "formats = nftables, nginx", key: "formats", value: "nftables, nginx", isSetting: true},
+		{line: "AWS-Region=eu-west-1", key: "aws-region", value: "eu-west-1", isSetting: true},
+		{line: `out = "./generated"`, key: "out", value: "./generated", isSetting: true},
+		{line: "header = a=b", key: "header", value: "a=b", isSetting: true},
+		{line: "empty =", key: "empty", value: "", isSetting: true},
+		{line: "10.0.0.0/8"},
+		{line: "10.0.0.0/8 replacement=10.64.0.0/10"},
+		{line: "AzureCloud.WestEurope"},
+		{line: "two words = x"},
+		{line: "= value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			key, value, ok := parseConfigValue(tt.line)
+			if key != tt.key || value != tt.value || ok != tt.isSetting {
+				t.Errorf("parseConfigValue = %q, %q, %v; want %q, %q, %v", key, value, ok, tt.key, tt.value, tt.isSetting)
+			}
+		})
+	}
+}
+
+func TestConfigParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		entries []configEntry
+		groups  []string
+		values  map[string]string
+		wantErr string
+	}{
+		{
+			name: "ungrouped ranges and comments",
+			data: "# office ranges\n10.0.0.0/8\n\n   192.168.0.0/16   \n",
+			entries: []configEntry{
+				{Value: "10.0.0.0/8", Source: "test", Line: 2},
+				{Value: "192.168.0.0/16", Source: "test", Line: 4},
+			},
+		},
+		{
+			name: "groups",
+			data: "10.0.0.0/8\n[group \"office\"]\n10.1.0.0/16\nAzureCloud.WestEurope\n[ group \"vpn\" ]\n10.8.0.0/16\n[group \"office\"]\n10.2.0.0/16\n",
+			entries: []configEntry{
+				{Value: "10.0.0.0/8", Source: "test", Line: 1},
+				{Value: "10.1.0.0/16", Group: "office", Source: "test", Line: 3},
+				{Value: "AzureCloud.WestEurope", Group: "office", Source: "test", Line: 4},
+				{Value: "10.8.0.0/16", Group: "vpn", Source: "test", Line: 6},
+				{Value: "10.2.0.0/16", Group: "office", Source: "test", Line: 8},
+			},
+			groups: []string{"office", "vpn"},
+		},
+		{
+			name: "settings",
+			data: "[Render]\nformats = nginx\n[resolve]\nAWS-Region = \"eu-west-1\"\n[timeout \"Azure\"]\nseconds = 5\n",
+			values: map[string]string{
+				"render.formats":        "nginx",
+				"resolve.aws-region":    "eu-west-1",
+				"timeout.Azure.seconds": "5",
+			},
+		},
+		{
+			name: "settings and ranges in a group",
+			data: "[group \"web\"]\nport = 443\n10.0.0.0/8\n",
+			entries: []configEntry{
+				{Value: "10.0.0.0/8", Group: "web", Source: "test", Line: 3},
+			},
+			groups: []string{"web"},
+			values: map[string]string{"group.web.port": "443"},
+		},
+		{
+			name: "range attributes",
+			data: "[group \"old\"]\n10.0.0.0/8 deprecated replacement=10.64.0.0/10\n172.16.0.0/12 DEPRECATED\n192.168.0.0/16 replacement=10.65.0.0/16\n10.3.0.0/16 replacement=\n",
+			entries: []configEntry{
+				{Value: "10.0.0.0/8", Group: "old", Deprecated: true, Replacement: "10.64.0.0/10", Source: "test", Line: 2},
+				{Value: "172.16.0.0/12", Group: "old", Deprecated: true, Source: "test", Line: 3},
+				{Value: "192.168.0.0/16", Group: "old", Deprecated: true, Replacement: "10.65.0.0/16", Source: "test", Line: 4},
+				{Value: "10.3.0.0/16", Group: "old", Deprecated: true, Source: "test", Line: 5},
+			},
+			groups: []string{"old"},
+		},
+		{
+			name:    "setting outside a section",
+			data:    "10.0.0.0/8\nformats = nginx\n",
+			wantErr: "line 2: setting 'formats' outside a section",
+		},
+		{
+			name:    "range outside a group",
+			data:    "[render]\n10.0.0.0/8\n",
+			wantErr: "line 2: range '10.0.0.0/8' must be in a group section",
+		},
+		{
+			name:    "unquoted group name",
+			data:    "[group office]\n",
+			wantErr: "line 1: invalid section header '[group office]'",
+		},
+		{
+			name:    "unnamed group",
+			data:    "[group]\n10.0.0.0/8\n",
+			wantErr: "line 1: group section needs a name",
+		},
+		{
+			name:    "unknown attribute",
+			data:    "10.0.0.0/8 retired\n",
+			wantErr: "line 1: unknown range attribute 'retired'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &cidrConfig{Values: make(map[string]string), Origins: make(map[string]string)}
+			err := cfg.parse(tt.data, "test")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			if !slices.Equal(cfg.Entries, tt.entries) {
+				t.Errorf("entries = %+v, want %+v", cfg.Entries, tt.entries)
+			}
+			if !slices.Equal(cfg.Groups, tt.groups) {
+				t.Errorf("groups = %v, want %v", cfg.Groups, tt.groups)
+			}
+			if tt.values == nil {
+				tt.values = map[string]string{}
+			}
+			if !maps.Equal(cfg.Values, tt.values) {
+				t.Errorf("values = %v, want %v", cfg.Values, tt.values)
+			}
+		})
+	}
+}
+
+func TestConfigLayers(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	t.Setenv("HOME", home)
+
+	oldConfig := configFile
+	t.Cleanup(func() { configFile = oldConfig })
+
+	tests := []struct {
+		name       string
+		configFile string
+		dir        string
+		want       []configLayer
+	}{
+		{
+			name: "cascade",
+			dir:  project,
+			want: []configLayer{
+				{Scope: "system", Path: systemConfigPath},
+				{Scope: "user", Path: filepath.Join(home, ".cidr")},
+				{Scope: "project", Path: filepath.Join(project, ".cidr")},
+			},
+		},
+		{
+			name: "run from the home directory",
+			dir:  home,
+			want: []configLayer{
+				{Scope: "system", Path: systemConfigPath},
+				{Scope: "user", Path: filepath.Join(home, ".cidr")},
+			},
+		},
+		{
+			name:       "config flag",
+			configFile: "ranges.conf",
+			dir:        project,
+			want:       []configLayer{{Scope: "command", Path: "ranges.conf"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(tt.dir)
+			configFile = tt.configFile
+			layers, err := configLayers()
+			if err != nil {
+				t.Fatalf("configLayers: %v", err)
+			}
+			if !slices.Equal(layers, tt.want) {
+				t.Errorf("layers = %+v, want %+v", layers, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	project := filepath.Join(dir, "project")
+	for _, path := range []string{home, project} {
+		if err := os.Mkdir(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	system := filepath.Join(dir, "system")
+	user := filepath.Join(home, ".cidr")
+	local := filepath.Join(project, ".cidr")
+	command := filepath.Join(dir, "command")
+
+	files := map[string]string{
+		system:  "10.0.0.0/8\n[group \"office\"]\n10.1.0.0/16\n[render]\nformats = nftables\nout = /srv/cidr\n",
+		user:    "[group \"office\"]\n10.2.0.0/16\n[render]\nformats = nginx\n",
+		local:   "[group \"lab\"]\n10.3.0.0/16\n[render]\nout = ./generated\n",
+		command: "[group \"only\"]\n192.0.2.0/24\n",
+	}
+
+	oldSystem, oldConfig := systemConfigPath, configFile
+	t.Cleanup(func() { systemConfigPath, configFile = oldSystem, oldConfig })
+	systemConfigPath = system
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name       string
+		files      []string // which of the files above exist
+		dir        string
+		configFile string
+		broken     string // a file that gets a syntax error
+		entries    []string
+		groups     []string
+		values     map[string]string // setting to origin file and line
+		path       string
+		wantErr    string
+		notExist   bool
+	}{
+		{
+			name:    "all layers",
+			files:   []string{system, user, local},
+			dir:     project,
+			entries: []string{"10.0.0.0/8", "10.1.0.0/16", "10.2.0.0/16", "10.3.0.0/16"},
+			groups:  []string{"office", "lab"},
+			values: map[string]string{
+				"render.formats=nginx":   user + ":4",
+				"render.out=./generated": local + ":4",
+			},
+			path: system + ", " + user + ", " + local,
+		},
+		{
+			name:    "system only",
+			files:   []string{system},
+			dir:     project,
+			entries: []string{"10.0.0.0/8", "10.1.0.0/16"},
+			groups:  []string{"office"},
+			values: map[string]string{
+				"render.formats=nftables": system + ":5",
+				"render.out=/srv/cidr":    system + ":6",
+			},
+			path: system,
+		},
+		{
+			name:    "user and project",
+			files:   []string{user, local},
+			dir:     project,
+			entries: []string{"10.2.0.0/16", "10.3.0.0/16"},
+			groups:  []string{"office", "lab"},
+			path:    user + ", " + local,
+		},
+		{
+			name:    "home directory is read once",
+			files:   []string{user},
+			dir:     home,
+			entries: []string{"10.2.0.0/16"},
+			groups:  []string{"office"},
+			path:    user,
+		},
+		{
+			name:       "config flag replaces the cascade",
+			files:      []string{system, user, local, command},
+			dir:        project,
+			configFile: command,
+			entries:    []string{"192.0.2.0/24"},
+			groups:     []string{"only"},
+			path:       command,
+		},
+		{
+			name:     "no config",
+			dir:      project,
+			wantErr:  "no config file found (looked for " + system + ", " + user + ", " + local + ")",
+			notExist: true,
+		},
+		{
+			name:       "missing config flag file",
+			files:      []string{system, user},
+			dir:        project,
+			configFile: command,
+			wantErr:    command,
+			notExist:   true,
+		},
+		{
+			name:    "syntax error names the file",
+			files:   []string{system, user, local},
+			dir:     project,
+			broken:  user,
+			wantErr: user + ": line 5: setting 'broken' outside a section",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for path, data := range files {
+				os.Remove(path)
+				if !slices.Contains(tt.files, path) {
+					continue
+				}
+				if path == tt.broken {
+					data = "10.9.0.0/16\n\n\n\nbroken = yes\n"
+				}
+				writeConfig(t, filepath.Dir(path), filepath.Base(path), data)
+			}
+			t.Chdir(tt.dir)
+			configFile = tt.configFile
+
+			cfg, err := loadConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if errors.Is(err, fs.ErrNotExist) != tt.notExist {
+					t.Errorf("errors.Is(%v, fs.ErrNotExist) = %v, want %v", err, !tt.notExist, tt.notExist)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig: %v", err)
+			}
+
+			var entries []string
+			for _, entry := range cfg.Entries {
+				entries = append(entries, entry.Value)
+			}
+			if !slices.Equal(entries, tt.entries) {
+				t.Errorf("entries = %v, want %v", entries, tt.entries)
+			}
+			if !slices.Equal(cfg.Groups, tt.groups) {
+				t.Errorf("groups = %v, want %v", cfg.Groups, tt.groups)
+			}
+			for setting, origin := range tt.values {
+				key, value, _ := strings.Cut(setting, "=")
+				if cfg.value(key) != value || cfg.origin(key) != origin {
+					t.Errorf("%s = %q from %s, want %q from %s", key, cfg.value(key), cfg.origin(key), value, origin)
+				}
+			}
+			if cfg.Path != tt.path {
+				t.Errorf("path = %q, want %q", cfg.Path, tt.path)
+			}
+			for _, layer := range cfg.Layers {
+				if layer.Loaded != slices.Contains(tt.files, layer.Path) {
+					t.Errorf("layer %s loaded = %v", layer.Path, layer.Loaded)
+				}
+			}
+		})
+	}
+}
+
+// testServiceTags writes a service tags file and returns a config that
+// uses it, parsed from data.
+func testServiceTags(t *testing.T, data string) *cidrConfig {
+	t.Helper()
+	tags := writeConfig(t, t.TempDir(), "ServiceTags_Public.json", `{"changeNumber": 7, "values": [
+		{"name": "AzureCloud.WestEurope", "id": "AzureCloud.WestEurope", "properties": {"addressPrefixes": ["13.69.0.0/17", "2603:1020::/47"]}}
+	]}`)
+	cfg := &cidrConfig{Values: make(map[string]string), Origins: make(map[string]string)}
+	if err := cfg.parse("[resolve]\nazure-service-tags = "+tags+"\n"+data, "test"); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestResolveEntries(t *testing.T) {
+	cfg := testServiceTags(t, "[group \"office\"]\n10.1.0.0/16\n10.2.0.0/16 deprecated\n"+
+		"[group \"cloud\"]\nAzureCloud.WestEurope\n[group \"vpn\"]\n10.8.0.0/16 replacement=10.9.0.0/16\n")
+
+	inGroup := func(group string) func(configEntry) bool {
+		return func(entry configEntry) bool { return entry.Group == group }
+	}
+
+	tests := []struct {
+		name              string
+		keep              func(configEntry) bool
+		excludeDeprecated bool
+		want              []string
+	}{
+		{
+			name: "all",
+			keep: func(configEntry) bool { return true },
+			want: []string{"10.1.0.0/16", "10.2.0.0/16", "13.69.0.0/17", "2603:1020::/47", "10.8.0.0/16"},
+		},
+		{
+			name: "one group",
+			keep: inGroup("office"),
+			want: []string{"10.1.0.0/16", "10.2.0.0/16"},
+		},
+		{
+			name: "service tag",
+			keep: inGroup("cloud"),
+			want: []string{"13.69.0.0/17", "2603:1020::/47"},
+		},
+		{
+			name:              "without deprecated",
+			keep:              func(configEntry) bool { return true },
+			excludeDeprecated: true,
+			want:              []string{"10.1.0.0/16", "13.69.0.0/17", "2603:1020::/47"},
+		},
+		{
+			name: "nothing",
+			keep: inGroup("missing"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.ExcludeDeprecated = tt.excludeDeprecated
+			got, err := cfg.resolveEntries(tt.keep)
+			if err != nil {
+				t.Fatalf("resolveEntries: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("resolveEntries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// An unknown tag reports where it was configured
+	bad := testServiceTags(t, "[group \"cloud\"]\n10.0.0.0/8\nAzureCloud.Mars\n")
+	_, err := bad.resolveEntries(func(configEntry) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "test:5: unknown Azure service tag 'AzureCloud.Mars'") {
+		t.Errorf("error = %v, want the unknown tag and its line", err)
+	}
+}
+
+func TestDeprecations(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "replacements",
+			data: "[group \"old\"]\n10.0.0.0/8 replacement=10.64.0.0/10\n172.16.0.0/12 deprecated\n192.168.0.0/16\n",
+			want: map[string]string{"10.0.0.0/8": "10.64.0.0/10", "172.16.0.0/12": ""},
+		},
+		{
+			name: "canonical form",
+			data: "10.1.2.3/16 deprecated\n2001:DB8:0::/32 replacement=2001:db8:1::/48\n",
+			want: map[string]string{"10.1.0.0/16": "", "2001:db8::/32": "2001:db8:1::/48"},
+		},
+		{
+			name: "service tag",
+			data: "AzureCloud.WestEurope replacement=10.0.0.0/8\n",
+			want: map[string]string{"13.69.0.0/17": "10.0.0.0/8", "2603:1020::/47": "10.0.0.0/8"},
+		},
+		{
+			name: "none",
+			data: "10.0.0.0/8\n",
+			want: map[string]string{},
+		},
+		{
+			name:    "unknown service tag",
+			data:    "10.0.0.0/8\nAzureCloud.Mars deprecated\n",
+			wantErr: "test:2: unknown Azure service tag 'AzureCloud.Mars'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A second file so that ranges are not in the [resolve] section
+			cfg := testServiceTags(t, "")
+			if err := cfg.parse(tt.data, "test"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := cfg.deprecations()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("deprecations: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("deprecations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var convertIPv6 bool
+
+var convertCmd = &cobra.Command{
+	Use:   "convert VALUE...",
+	Short: "Convert addresses between notations, integers and PTR names",
+	Long: titleStyle.Render("Address Conversion") + "\n\n" +
+		"Show an address as network-order (big-endian) and host-order (little-endian)\n" +
+		"integers, in hex, and as the exact PTR query name for reverse DNS.\n\n" +
+		"VALUE may be an IPv4 or IPv6 address, a decimal or 0x-prefixed hex integer, or\n" +
+		"a PTR name (in-addr.arpa or ip6.arpa). Integers are read in network order and\n" +
+		"the byte-swapped reading is shown too, for values taken from databases that\n" +
+		"store addresses in host order. Integers up to 2^32-1 are IPv4 unless --ipv6\n" +
+		"is given. IPv4-mapped addresses (::ffff:a.b.c.d) are shown as IPv6.",
+	Example: `  cidr convert 192.168.1.10
+  cidr convert 3232235786
+  cidr convert 0x0a01a8c0
+  cidr convert 10.1.168.192.in-addr.arpa
+  cidr convert 2001:db8::1`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runConvert,
+}
+
+func init() {
+	convertCmd.Flags().BoolVarP(&convertIPv6, "ipv6", "6", false, "Read integers as IPv6 addresses")
+	rootCmd.AddCommand(convertCmd)
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	for i, value := range args {
+		ip, form, err := parseAddressValue(value, convertIPv6)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Println() // Separator between multiple values
+		}
+		fmt.Println(titleStyle.Render("Address Conversion"))
+		fmt.Printf("%s %s %s\n", labelStyle.Render("Input:"), valueStyle.Render(value), dimStyle.Render("("+form+")"))
+		fmt.Printf("%s %s\n", labelStyle.Render("Address:"), valueStyle.Render(addressString(ip)))
+		if form == "integer" {
+			swapped := reversedBytes(ip)
+			fmt.Printf("%s %s %s\n", labelStyle.Render("Byte-swapped:"), valueStyle.Render(addressString(swapped)),
+				dimStyle.Render("(if the value was stored in host order)"))
+		}
+		fmt.Println()
+
+		n := new(big.Int).SetBytes(ip)
+		host := new(big.Int).SetBytes(reversedBytes(ip))
+		digits := len(ip) * 2
+		fmt.Printf("%s %s %s\n", labelStyle.Render("Network Order:"), valueStyle.Render(n.String()), dimStyle.Render("(big-endian)"))
+		fmt.Printf("%s %s %s\n", labelStyle.Render("Host Order:"), valueStyle.Render(host.String()), dimStyle.Render("(little-endian)"))
+		fmt.Printf("%s %s\n", labelStyle.Render("Hex:"), valueStyle.Render(fmt.Sprintf("0x%0*x", digits, n)))
+		fmt.Printf("%s %s\n", labelStyle.Render("Hex (Host Order):"), valueStyle.Render(fmt.Sprintf("0x%0*x", digits, host)))
+		fmt.Printf("%s %s\n", labelStyle.Render("PTR:"), valueStyle.Render(ptrName(ip)))
+	}
+
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr convert --help' for more options"))
+
+	return nil
+}
+
+// parseAddressValue reads an address given as an IP, an integer or a PTR
+// name, and reports which form it was. IPv4 addresses are returned as
+// 4 bytes, IPv6 as 16. IPv4-mapped addresses stay IPv6, as written, so
+// they get 128-bit integers and an ip6.arpa name.
+func parseAddressValue(s string, ipv6 bool) (net.IP, string, error) {
+	lower := strings.TrimSuffix(strings.ToLower(s), ".")
+	if strings.HasSuffix(lower, ".in-addr.arpa") || strings.HasSuffix(lower, ".ip6.arpa") {
+		ip, err := parsePTRName(lower)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid PTR name '%s': %w", s, err)
+		}
+		return ip, "PTR name", nil
+	}
+
+	if addr, err := netip.ParseAddr(s); err == nil && addr.Zone() == "" {
+		switch {
+		case addr.Is4():
+			return net.IP(addr.AsSlice()), "IPv4 address", nil
+		case addr.Is4In6():
+			return net.IP(addr.AsSlice()), "IPv4-mapped IPv6 address", nil
+		}
+		return net.IP(addr.AsSlice()), "IPv6 address", nil
+	}
+
+	n, ok := new(big.Int), false
+	if hexDigits, found := strings.CutPrefix(lower, "0x"); found {
+		_, ok = n.SetString(hexDigits, 16)
+	} else if lower != "" && strings.Trim(lower, "0123456789") == "" {
+		_, ok = n.SetString(lower, 10)
+	}
+	if !ok {
+		return nil, "", fmt.Errorf("invalid address '%s': expected an IP address, integer or PTR name", s)
+	}
+
+	bits := 32
+	if ipv6 || n.BitLen() > 32 {
+		bits = 128
+	}
+	if n.BitLen() > bits {
+		return nil, "", fmt.Errorf("integer '%s' is larger than any IPv6 address", s)
+	}
+	return intToIP(n, bits), "integer", nil
+}
+
+// ptrName returns the fully qualified reverse DNS query name for ip: an
+// in-addr.arpa name for 4-byte addresses, ip6.arpa for 16-byte ones
+// (including IPv4-mapped addresses).
+func ptrName(ip net.IP) string {
+	var labels []string
+	if len(ip) == net.IPv4len {
+		for _, b := range slices.Backward(ip) {
+			labels = append(labels, strconv.Itoa(int(b)))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa."
+	}
+
+	for _, b := range slices.Backward(ip) {
+		labels = append(labels, strconv.FormatUint(uint64(b&0x0f), 16), strconv.FormatUint(uint64(b>>4), 16))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa."
+}
+
+// parsePTRName reads a full reverse DNS name (lowercase, without the
+// trailing dot) back into an address.
+func parsePTRName(name string) (net.IP, error) {
+	if labels, ok := strings.CutSuffix(name, ".in-addr.arpa"); ok {
+		parts := strings.Split(labels, ".")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("expected 4 octets before in-addr.arpa, found %d", len(parts))
+		}
+		ip := make(net.IP, 4)
+		for i, part := range parts {
+			octet, err := strconv.ParseUint(part, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid octet '%s'", part)
+			}
+			ip[3-i] = byte(octet)
+		}
+		return ip, nil
+	}
+
+	labels, _ := strings.CutSuffix(name, ".ip6.arpa")
+	parts := strings.Split(labels, ".")
+	if len(parts) != 32 {
+		return nil, fmt.Errorf("expected 32 nibbles before ip6.arpa, found %d", len(parts))
+	}
+	ip := make(net.IP, 16)
+	for i, part := range parts {
+		nibble, err := strconv.ParseUint(part, 16, 4)
+		if err != nil || len(part) != 1 {
+			return nil, fmt.Errorf("invalid nibble '%s'", part)
+		}
+		// Nibbles are least significant first
+		pos := 31 - i
+		ip[pos/2] |= byte(nibble) << (4 * (1 - pos%2))
+	}
+	return ip, nil
+}
+
+// addressString formats ip by its length, so a 16-byte IPv4-mapped address
+// prints as ::ffff:a.b.c.d rather than as IPv4.
+func addressString(ip []byte) string {
+	addr, _ := netip.AddrFromSlice(ip)
+	return addr.String()
+}
+
+// reversedBytes returns the bytes of ip in reverse order, the host-order
+// layout on little-endian machines.
+func reversedBytes(ip net.IP) []byte {
+	b := slices.Clone(ip)
+	slices.Reverse(b)
+	return b
+}
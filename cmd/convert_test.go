@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestPTRNameRoundTrip(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"192.0.2.1", "1.2.0.192.in-addr.arpa."},
+		{"10.0.0.0", "0.0.0.10.in-addr.arpa."},
+		{"255.255.255.255", "255.255.255.255.in-addr.arpa."},
+		{"2001:db8::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+		{"2001:db8:abcd:12::f0", "0.f.0.0.0.0.0.0.0.0.0.0.0.0.0.0.2.1.0.0.d.c.b.a.8.b.d.0.1.0.0.2.ip6.arpa."},
+		{"::", strings.Repeat("0.", 32) + "ip6.arpa."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if v4 := ip.To4(); v4 != nil {
+				ip = v4
+			}
+			name := ptrName(ip)
+			if name != tt.want {
+				t.Errorf("ptrName = %s, want %s", name, tt.want)
+			}
+
+			// parseAddressValue takes the name as typed, with or without the
+			// trailing dot and in any case
+			for _, input := range []string{name, strings.TrimSuffix(name, "."), strings.ToUpper(name)} {
+				back, form, err := parseAddressValue(input, false)
+				if err != nil {
+					t.Fatalf("parseAddressValue(%s): %v", input, err)
+				}
+				if form != "PTR name" || !back.Equal(ip) || len(back) != len(ip) {
+					t.Errorf("parseAddressValue(%s) = %v (%s), want %v", input, back, form, ip)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertMapped(t *testing.T) {
+	// IPv4-mapped addresses stay IPv6 however they are written, so the
+	// integer and PTR name are the IPv6 ones
+	mapped := "1.0.2.0.0.0.0.c.f.f.f.f.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa."
+	tests := []struct {
+		value string
+		ipv6  bool
+		want  string
+		ptr   string
+	}{
+		{"::ffff:192.0.2.1", false, "::ffff:192.0.2.1", mapped},
+		{"::FFFF:c000:201", false, "::ffff:192.0.2.1", mapped},
+		{"0xffffc0000201", false, "::ffff:192.0.2.1", mapped},
+		{"281473902969345", false, "::ffff:192.0.2.1", mapped},
+		{strings.TrimSuffix(mapped, "."), false, "::ffff:192.0.2.1", mapped},
+		{"3221225985", true, "::c000:201", "1.0.2.0.0.0.0.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa."},
+		{"192.0.2.1", false, "192.0.2.1", "1.2.0.192.in-addr.arpa."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			ip, _, err := parseAddressValue(tt.value, tt.ipv6)
+			if err != nil {
+				t.Fatalf("parseAddressValue: %v", err)
+			}
+			if got := addressString(ip); got != tt.want {
+				t.Errorf("address = %s, want %s", got, tt.want)
+			}
+			if got := ptrName(ip); got != tt.ptr {
+				t.Errorf("ptrName = %s, want %s", got, tt.ptr)
+			}
+		})
+	}
+}
+
+func TestParsePTRNameErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"2.0.192.in-addr.arpa", "expected 4 octets before in-addr.arpa, found 3"}, // a /24 zone, not an address
+		{"0/25.2.0.192.in-addr.arpa", "invalid octet '0/25'"},                      // RFC 2317 classless delegation
+		{"1.2.0.192.10.in-addr.arpa", "expected 4 octets before in-addr.arpa, found 5"},
+		{"256.2.0.192.in-addr.arpa", "invalid octet '256'"},
+		{"x.2.0.192.in-addr.arpa", "invalid octet 'x'"},
+		{"-1.2.0.192.in-addr.arpa", "invalid octet '-1'"},
+		{"1..0.192.in-addr.arpa", "invalid octet ''"},
+		{"8.b.d.0.1.0.0.2.ip6.arpa", "expected 32 nibbles before ip6.arpa, found 8"}, // 2001:db8::/32 zone
+		{strings.Repeat("0.", 31) + "g.ip6.arpa", "invalid nibble 'g'"},
+		{strings.Repeat("0.", 31) + "10.ip6.arpa", "invalid nibble '10'"},
+		{strings.Repeat("0.", 31) + "+1.ip6.arpa", "invalid nibble '+1'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := parsePTRName(tt.name)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("parsePTRName = %v, %v; want error %q", ip, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAddressValue(t *testing.T) {
+	tests := []struct {
+		value string
+		ipv6  bool
+		want  string
+		form  string
+	}{
+		{"192.0.2.1", false, "192.0.2.1", "IPv4 address"},
+		{"::ffff:192.0.2.1", false, "::ffff:192.0.2.1", "IPv4-mapped IPv6 address"},
+		{"2001:db8::1", false, "2001:db8::1", "IPv6 address"},
+		{"3221225985", false, "192.0.2.1", "integer"},
+		{"0xc0000201", false, "192.0.2.1", "integer"},
+		{"0XC0000201", false, "192.0.2.1", "integer"},
+		{"16908480", false, "1.2.0.192", "integer"}, // 192.0.2.1 in host order
+		{"0", false, "0.0.0.0", "integer"},
+		{"4294967295", false, "255.255.255.255", "integer"},
+		{"4294967296", false, "::1:0:0", "integer"},
+		{"1", true, "::1", "integer"},
+		{"0x20010db8000000000000000000000001", false, "2001:db8::1", "integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			ip, form, err := parseAddressValue(tt.value, tt.ipv6)
+			if err != nil {
+				t.Fatalf("parseAddressValue: %v", err)
+			}
+			if addressString(ip) != tt.want || form != tt.form {
+				t.Errorf("parseAddressValue = %s (%s), want %s (%s)", addressString(ip), form, tt.want, tt.form)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "-1", "1.2.3", "fe80::1%eth0", "0x", "0xg", "12ab", "0x1" + strings.Repeat("0", 32)} {
+		if ip, _, err := parseAddressValue(value, false); err == nil {
+			t.Errorf("parseAddressValue(%q) = %v, want an error", value, ip)
+		}
+	}
+}
+
+func TestByteOrder(t *testing.T) {
+	tests := []struct {
+		ip           string
+		network      string
+		host         string
+		swappedIP    string
+		networkBytes int
+	}{
+		{"192.0.2.1", "3221225985", "16908480", "1.2.0.192", 4},
+		{"10.0.0.1", "167772161", "16777226", "1.0.0.10", 4},
+		{"2001:db8::1", "42540766411282592856903984951653826561", "1329227995784915872903807063368204576", "100::b80d:120", 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip, _, err := parseAddressValue(tt.ip, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ip) != tt.networkBytes {
+				t.Fatalf("%s is %d bytes, want %d", tt.ip, len(ip), tt.networkBytes)
+			}
+
+			n := new(big.Int).SetBytes(ip)
+			if n.String() != tt.network {
+				t.Errorf("network order = %s, want %s", n, tt.network)
+			}
+			swapped := reversedBytes(ip)
+			if host := new(big.Int).SetBytes(swapped); host.String() != tt.host {
+				t.Errorf("host order = %s, want %s", host, tt.host)
+			}
+			if got := addressString(swapped); got != tt.swappedIP {
+				t.Errorf("byte-swapped address = %s, want %s", got, tt.swappedIP)
+			}
+
+			// Reading the host-order integer and swapping it back gives
+			// the original address
+			back, _, err := parseAddressValue(tt.host, tt.networkBytes == 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := net.IP(reversedBytes(back)); !got.Equal(ip) {
+				t.Errorf("swapping %s back = %s, want %s", tt.host, got, ip)
+			}
+		})
+	}
+
+	// reversedBytes leaves its argument alone
+	ip := net.IP{192, 0, 2, 1}
+	reversedBytes(ip)
+	if ip.String() != "192.0.2.1" {
+		t.Errorf("reversedBytes modified its argument: %s", ip)
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	coverageObserved string
+	coverageLimit    int
+)
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Compare configured ranges with observed addresses",
+	Long: titleStyle.Render("Coverage Report") + "\n\n" +
+		"Match a list of observed IP addresses against the configured ranges and report:\n" +
+		"  - ranges that matched no observed address (candidates for cleanup)\n" +
+		"  - observed addresses that matched no range (candidates for documentation)\n" +
+		"  - per group, the share of ranges hit and of addresses matched\n\n" +
+		"Reads the list from stdin when --observed is '-'.",
+	Example: `  cidr coverage --observed ips.txt
+  awk '{print $1}' access.log | cidr coverage --observed - --group office`,
+	Args: cobra.NoArgs,
+	RunE: runCoverage,
+}
+
+func init() {
+	coverageCmd.Flags().StringVar(&coverageObserved, "observed", "", "File of observed IP addresses, one per line")
+	coverageCmd.Flags().IntVar(&coverageLimit, "limit", 25, "Maximum unmatched addresses to list (0 for all)")
+	coverageCmd.MarkFlagRequired("observed")
+	rootCmd.AddCommand(coverageCmd)
+}
+
+func runCoverage(cmd *cobra.Command, args []string) error {
+	sets, err := collectExportSets(nil)
+	if err != nil {
+		return err
+	}
+	matcher := newRangeMatcher(sets)
+
+	lines, err := readListFile(coverageObserved)
+	if err != nil {
+		return err
+	}
+
+	var observed []net.IP
+	seen := make(map[string]bool)
+	invalid := 0
+	for _, line := range lines {
+		ip := net.ParseIP(line)
+		if ip == nil {
+			invalid++
+			continue
+		}
+		if !seen[ip.String()] {
+			seen[ip.String()] = true
+			observed = append(observed, ip)
+		}
+	}
+	if len(observed) == 0 {
+		return fmt.Errorf("no valid IP addresses in %s", coverageObserved)
+	}
+
+	// Hits per range and matched addresses per group
+	rangeHits := make(map[string]int)
+	groupAddrs := make(map[string]int)
+	var unmatched []net.IP
+	for _, ip := range observed {
+		matches := matcher.match(ip)
+		if len(matches) == 0 {
+			unmatched = append(unmatched, ip)
+			continue
+		}
+		for _, entry := range matches {
+			rangeHits[entry.Group+" "+entry.Net.String()]++
+		}
+		for _, group := range matchedGroups(matches) {
+			groupAddrs[group]++
+		}
+	}
+	sort.Slice(unmatched, func(i, j int) bool { return compareIPs(unmatched[i], unmatched[j]) < 0 })
+
+	fmt.Println(titleStyle.Render("Coverage Report"))
+	fmt.Printf("%s %s\n", labelStyle.Render("Observed:"),
+		valueStyle.Render(fmt.Sprintf("%d addresses (%d unique)", len(lines)-invalid, len(observed))))
+	if invalid > 0 {
+		fmt.Fprintln(os.Stderr, infoStyle.Render(fmt.Sprintf("Skipped %d invalid line(s)", invalid)))
+	}
+	fmt.Println()
+
+	fmt.Println(titleStyle.Render("Groups"))
+	type unusedRange struct {
+		group string
+		net   *net.IPNet
+	}
+	var unused []unusedRange
+	for _, set := range sets {
+		hit := 0
+		for _, ipnet := range set.CIDRs {
+			if rangeHits[set.Name+" "+ipnet.String()] > 0 {
+				hit++
+			} else {
+				unused = append(unused, unusedRange{set.Name, ipnet})
+			}
+		}
+		fmt.Printf("%s %s %s\n",
+			labelStyle.Render(set.Name+":"),
+			valueStyle.Render(fmt.Sprintf("%d/%d ranges hit (%s)", hit, len(set.CIDRs), percent(hit, len(set.CIDRs)))),
+			dimStyle.Render(fmt.Sprintf("%d of %d addresses (%s)", groupAddrs[set.Name], len(observed), percent(groupAddrs[set.Name], len(observed)))))
+	}
+	fmt.Println()
+
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Unused Ranges (%d)", len(unused))))
+	if len(unused) == 0 {
+		fmt.Println(successStyle.Render("Every range matched at least one observed address"))
+	}
+	for _, r := range unused {
+		fmt.Printf("%s %s %s\n", infoStyle.Render("○"), valueStyle.Render(r.net.String()), dimStyle.Render(r.group))
+	}
+	fmt.Println()
+
+	fmt.Println(titleStyle.Render(fmt.Sprintf("Unmatched Addresses (%d, %s)", len(unmatched), percent(len(unmatched), len(observed)))))
+	if len(unmatched) == 0 {
+		fmt.Println(successStyle.Render("Every observed address matched a range"))
+	}
+	for i, ip := range unmatched {
+		if coverageLimit > 0 && i == coverageLimit {
+			fmt.Println(dimStyle.Render(fmt.Sprintf("... and %d more (use --limit 0 to list all)", len(unmatched)-coverageLimit)))
+			break
+		}
+		fmt.Printf("%s %s %s\n", infoStyle.Render("○"), valueStyle.Render(ip.String()), dimStyle.Render(classifyIP(ip)))
+	}
+
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr coverage --help' for more options"))
+
+	return nil
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)/float64(total)*100)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var egressCmd = &cobra.Command{
+	Use:   "egress IP [CIDR...]",
+	Short: "Show which interface and source address would reach an IP",
+	Long: titleStyle.Render("Egress Check") + "\n\n" +
+		"Ask the host's routing table which local interface and source address it would\n" +
+		"use to reach an IP, and check that the source address is inside an approved\n" +
+		"range: the CIDRs given after the IP, the --group, or every config range.\n" +
+		"Useful for debugging VPN split tunnels.\n\n" +
+		"No traffic is sent: the route is looked up by connecting a UDP socket.\n" +
+		"Exits non-zero when the source address is not in an approved range.",
+	Example: `  cidr egress 10.20.30.40
+  cidr egress 10.20.30.40 --group vpn
+  cidr egress 2001:db8::10 2001:db8:ff::/48`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runEgress,
+}
+
+func init() {
+	rootCmd.AddCommand(egressCmd)
+}
+
+// egressRoute is the local end the kernel picked for a destination.
+type egressRoute struct {
+	Source    net.IP
+	Interface *net.Interface
+	Network   *net.IPNet // the interface network the source address is in
+}
+
+func runEgress(cmd *cobra.Command, args []string) error {
+	// Link-local destinations need a zone (fe80::1%eth0) to be routable
+	addr, zone, _ := strings.Cut(args[0], "%")
+	dest := net.ParseIP(addr)
+	if dest == nil {
+		return fmt.Errorf("invalid IP address: %s", args[0])
+	}
+	if dest.IsLinkLocalUnicast() && dest.To4() == nil && zone == "" {
+		return fmt.Errorf("link-local address %s needs an interface zone, e.g. %s%%eth0", dest, dest)
+	}
+	cmd.SilenceUsage = true
+
+	route, err := lookupEgress(dest, zone)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(titleStyle.Render("Egress Check"))
+	fmt.Printf("%s %s\n", labelStyle.Render("Destination:"), valueStyle.Render(args[0]))
+	fmt.Printf("%s %s\n", labelStyle.Render("Source:"), valueStyle.Render(route.Source.String()))
+	if route.Interface != nil {
+		fmt.Printf("%s %s %s\n", labelStyle.Render("Interface:"), valueStyle.Render(route.Interface.Name),
+			dimStyle.Render("("+interfaceSummary(route.Interface)+")"))
+	} else {
+		fmt.Printf("%s %s\n", labelStyle.Render("Interface:"), dimStyle.Render("unknown (source address not found on any interface)"))
+	}
+	if route.Network != nil {
+		fmt.Printf("%s %s\n", labelStyle.Render("Network:"), valueStyle.Render(route.Network.String()))
+		if route.Network.Contains(dest) {
+			fmt.Printf("%s %s\n", labelStyle.Render("Route:"), valueStyle.Render("on-link (destination is on the interface network)"))
+		} else {
+			fmt.Printf("%s %s\n", labelStyle.Render("Route:"), valueStyle.Render("via gateway"))
+		}
+	}
+	fmt.Println()
+
+	sets, err := collectExportSets(args[1:])
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Println(infoStyle.Render("○ No approved ranges configured; pass CIDRs after the IP or add them to the config"))
+		fmt.Println()
+		fmt.Println(helpStyle.Render("Run 'cidr egress --help' for more options"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	deprecated, err := loadDeprecations()
+	if err != nil {
+		return err
+	}
+
+	matches := newRangeMatcher(sets).match(route.Source)
+	for _, entry := range matches {
+		fmt.Printf("%s Source is in %s %s\n", successStyle.Render("✓"), valueStyle.Render(entry.Net.String()),
+			dimStyle.Render("("+entry.Group+")"))
+		if replacement, ok := deprecated[entry.Net.String()]; ok {
+			warning := fmt.Sprintf("  ⚠ %s is deprecated", entry.Net)
+			if replacement != "" {
+				warning += ", use " + replacement
+			}
+			fmt.Println(infoStyle.Render(warning))
+		}
+	}
+
+	if len(matches) == 0 {
+		ranges := 0
+		for _, set := range sets {
+			ranges += len(set.CIDRs)
+		}
+		fmt.Printf("%s Source is not in any of %d approved range(s)\n", errorStyle.Render("✗"), ranges)
+		fmt.Println()
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Traffic to %s would leave from %s, which is not in any approved range", dest, route.Source)))
+		return fmt.Errorf("source address %s is not approved", route.Source)
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("Source address is in an approved range"))
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr egress --help' for more options"))
+
+	return nil
+}
+
+// lookupEgress finds the source address and interface the host would use to
+// reach dest (in zone, for link-local addresses). Connecting a UDP socket
+// makes the kernel pick a route without sending any packets.
+func lookupEgress(dest net.IP, zone string) (*egressRoute, error) {
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: dest, Port: 9, Zone: zone})
+	if err != nil {
+		return nil, fmt.Errorf("no route to %s: %w", dest, err)
+	}
+	local := conn.LocalAddr().(*net.UDPAddr)
+	conn.Close()
+
+	route := &egressRoute{Source: local.IP}
+	if v4 := local.IP.To4(); v4 != nil {
+		route.Source = v4
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("could not list interfaces: %w", err)
+	}
+	for i := range ifaces {
+		iface := &ifaces[i]
+		// Link-local sources carry their interface as the zone
+		if local.Zone != "" && iface.Name != local.Zone {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || !ipnet.IP.Equal(route.Source) {
+				continue
+			}
+			route.Interface = iface
+			route.Network = &net.IPNet{IP: ipnet.IP.Mask(ipnet.Mask), Mask: ipnet.Mask}
+			return route, nil
+		}
+	}
+
+	return route, nil
+}
+
+// interfaceSummary describes the state of an interface, noting
+// point-to-point links, which are usually VPN tunnels.
+func interfaceSummary(iface *net.Interface) string {
+	var parts []string
+	if iface.Flags&net.FlagUp != 0 {
+		parts = append(parts, "up")
+	} else {
+		parts = append(parts, "down")
+	}
+	switch {
+	case iface.Flags&net.FlagLoopback != 0:
+		parts = append(parts, "loopback")
+	case iface.Flags&net.FlagPointToPoint != 0:
+		parts = append(parts, "point-to-point, likely a VPN tunnel")
+	}
+	if iface.MTU > 0 {
+		parts = append(parts, fmt.Sprintf("mtu %d", iface.MTU))
+	}
+	if len(iface.HardwareAddr) > 0 {
+		parts = append(parts, iface.HardwareAddr.String())
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	enrichField  string
+	enrichTarget string
+)
+
+var enrichCmd = &cobra.Command{
+	Use:   "enrich",
+	Short: "Enrich newline-delimited JSON events on stdin with range matches",
+	Long: titleStyle.Render("Streaming Enrichment") + "\n\n" +
+		"Read one JSON object per line on stdin and write it back on stdout with an\n" +
+		"added object describing the IP: its classification, whether it matched any\n" +
+		"configured range, and which groups and ranges matched.\n\n" +
+		"Exactly one line is written per non-empty input line, in order, and output is\n" +
+		"flushed after each line, so it can run as a long-lived exec processor in\n" +
+		"Logstash, Vector or Fluent Bit.",
+	Example: `  echo '{"ip": "10.1.2.3"}' | cidr enrich
+  cidr enrich --field source.ip --target source_cidr --group office`,
+	Args: cobra.NoArgs,
+	RunE: runEnrich,
+}
+
+func init() {
+	enrichCmd.Flags().StringVar(&enrichField, "field", "ip", "Field holding the IP address (dots address nested objects)")
+	enrichCmd.Flags().StringVar(&enrichTarget, "target", "cidr", "Field to write the enrichment object to")
+	rootCmd.AddCommand(enrichCmd)
+}
+
+// enrichResult is the object added to each event.
+type enrichResult struct {
+	IP             string            `json:"ip,omitempty"`
+	Valid          bool              `json:"valid"`
+	Classification string            `json:"classification,omitempty"`
+	InterfaceID    string            `json:"interface_id,omitempty"`
+	MAC            string            `json:"mac,omitempty"`
+	Match          bool              `json:"match"`
+	Groups         []string          `json:"groups"`
+	Matches        []string          `json:"matches"`
+	Deprecated     []deprecatedMatch `json:"deprecated,omitempty"`
+	Score          *int              `json:"score,omitempty"`
+	Signals        []scoreSignal     `json:"signals,omitempty"`
+	Error          string            `json:"error,omitempty"`
+}
+
+// deprecatedMatch is a matched range that is marked deprecated.
+type deprecatedMatch struct {
+	Range       string `json:"range"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// enricher holds everything built once at startup and used per event.
+type enricher struct {
+	matcher    *rangeMatcher
+	scorer     *ipScorer
+	deprecated map[string]string
+}
+
+func runEnrich(cmd *cobra.Command, args []string) error {
+	var e enricher
+	var err error
+	if e.matcher, err = newConfigMatcher(); err != nil {
+		return err
+	}
+	if e.scorer, err = loadScorer(); err != nil {
+		return err
+	}
+	if e.deprecated, err = loadDeprecations(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	writer := bufio.NewWriter(os.Stdout)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		out, err := e.enrichLine(line)
+		if err != nil {
+			return err
+		}
+		writer.Write(out)
+		writer.WriteByte('\n')
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// enrichLine adds the enrichment object to one input line. A line that is
+// not a JSON object is replaced by an object holding only the error, so
+// every input line still produces one output line.
+func (e *enricher) enrichLine(line string) ([]byte, error) {
+	var value any
+	var result enrichResult
+	// UseNumber keeps large integers in passed-through fields intact
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	event, isObject := value.(map[string]any)
+	switch {
+	case err != nil:
+		result = enrichResult{Groups: []string{}, Matches: []string{}, Error: "invalid JSON: " + err.Error()}
+	case !isObject:
+		result = enrichResult{Groups: []string{}, Matches: []string{}, Error: "invalid event: expected a JSON object"}
+	default:
+		result = e.enrich(lookupField(event, enrichField))
+	}
+	if !isObject {
+		event = make(map[string]any)
+	}
+	event[enrichTarget] = result
+
+	return json.Marshal(event)
+}
+
+// enrich classifies an address, matches it against the configured ranges
+// and scores it when a scorer is configured.
+func (e *enricher) enrich(value any) enrichResult {
+	result := enrichResult{Groups: []string{}, Matches: []string{}}
+
+	ipStr, ok := value.(string)
+	if !ok {
+		result.Error = fmt.Sprintf("field '%s' is missing or not a string", enrichField)
+		return result
+	}
+	result.IP = ipStr
+
+	ip := net.ParseIP(strings.TrimSpace(ipStr))
+	if ip == nil {
+		result.Error = "invalid IP address"
+		return result
+	}
+
+	result.Valid = true
+	result.Classification = classifyIP(ip)
+	if id, ok := analyzeInterfaceID(ip); ok {
+		result.InterfaceID = id.Kind
+		if id.MAC != nil {
+			result.MAC = id.MAC.String()
+		}
+	}
+
+	matches := e.matcher.match(ip)
+	result.Match = len(matches) > 0
+	result.Groups = matchedGroups(matches)
+	for _, entry := range matches {
+		result.Matches = append(result.Matches, entry.Net.String())
+		if replacement, ok := e.deprecated[entry.Net.String()]; ok {
+			result.Deprecated = append(result.Deprecated, deprecatedMatch{Range: entry.Net.String(), Replacement: replacement})
+		}
+	}
+
+	if e.scorer != nil {
+		score, signals := e.scorer.score(ip)
+		result.Score = &score
+		result.Signals = signals
+	}
+
+	return result
+}
+
+// lookupField follows a dotted path such as "source.ip" through nested
+// objects, falling back to a literal key containing dots.
+func lookupField(event map[string]any, path string) any {
+	if value, ok := event[path]; ok {
+		return value
+	}
+
+	var current any = event
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = obj[part]
+	}
+	return current
+}
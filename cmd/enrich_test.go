@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnrichLine(t *testing.T) {
+	office, err := newExportSet("office", []string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &enricher{
+		matcher:    newRangeMatcher([]exportSet{office}),
+		deprecated: map[string]string{"10.1.0.0/16": "10.64.0.0/10"},
+	}
+
+	tests := []struct {
+		name    string
+		line    string
+		ip      string
+		match   bool
+		wantErr string
+		keep    string // a passed-through field
+	}{
+		{name: "match", line: `{"ip": "10.1.2.3", "n": 12345678901234567890}`, ip: "10.1.2.3", match: true, keep: "n"},
+		{name: "no match", line: `{"ip": "192.0.2.1"}`, ip: "192.0.2.1"},
+		{name: "nested field", line: `{"source": {"ip": "10.1.0.1"}}`, wantErr: "field 'ip' is missing or not a string"},
+		{name: "bad address", line: `{"ip": "10.1.2"}`, ip: "10.1.2", wantErr: "invalid IP address"},
+		{name: "null", line: `null`, wantErr: "invalid event: expected a JSON object"},
+		{name: "array", line: `[{"ip": "10.1.2.3"}]`, wantErr: "invalid event: expected a JSON object"},
+		{name: "number", line: `42`, wantErr: "invalid event: expected a JSON object"},
+		{name: "string", line: `"10.1.2.3"`, wantErr: "invalid event: expected a JSON object"},
+		{name: "malformed", line: `{"ip": `, wantErr: "invalid JSON: unexpected EOF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := e.enrichLine(tt.line)
+			if err != nil {
+				t.Fatalf("enrichLine: %v", err)
+			}
+			var event map[string]json.RawMessage
+			if err := json.Unmarshal(out, &event); err != nil {
+				t.Fatalf("output %s is not an object: %v", out, err)
+			}
+			var result enrichResult
+			if err := json.Unmarshal(event["cidr"], &result); err != nil {
+				t.Fatalf("output %s has no enrichment: %v", out, err)
+			}
+
+			if result.IP != tt.ip || result.Match != tt.match || result.Error != tt.wantErr {
+				t.Errorf("result = %+v, want ip %q, match %v, error %q", result, tt.ip, tt.match, tt.wantErr)
+			}
+			if result.Match && (len(result.Deprecated) != 1 || result.Deprecated[0].Replacement != "10.64.0.0/10") {
+				t.Errorf("deprecated = %+v, want 10.1.0.0/16 replaced by 10.64.0.0/10", result.Deprecated)
+			}
+			if tt.keep != "" && string(event[tt.keep]) != "12345678901234567890" {
+				t.Errorf("field %s = %s, want it passed through unchanged", tt.keep, event[tt.keep])
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat     string
+	exportOutput     string
+	exportPort       int
+	exportMaxTargets int
+
+	setNamePattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// exportSet is a named list of ranges handed to an exporter. Each config
+// group becomes one set; ungrouped ranges form the "default" set. Meta holds
+// the group's settings, e.g. "port" and "label.env" from [group "name"].
+type exportSet struct {
+	Name  string
+	CIDRs []*net.IPNet
+	Meta  map[string]string
+}
+
+// exporter renders sets in a format understood by another tool. File is
+// the name used when rendering into a directory. Formats that expand ranges
+// into individual hosts are not rendered unless listed in render.formats.
+type exporter struct {
+	Description string
+	File        string
+	Expands     bool
+	Write       func(w io.Writer, sets []exportSet) error
+}
+
+var exporters = map[string]exporter{
+	"nftables": {
+		Description: "nftables named sets (include from your ruleset)",
+		File:        "cidr.nft",
+		Write:       writeNftables,
+	},
+	"sg-json": {
+		Description: "AWS security group IpPermissions JSON",
+		File:        "security-group.json",
+		Write:       writeSecurityGroupJSON,
+	},
+	"nginx": {
+		Description: "Nginx allow directives",
+		File:        "nginx-allow.conf",
+		Write:       writeNginx,
+	},
+	"prometheus-sd": {
+		Description: "Prometheus file_sd targets, one per host",
+		File:        "prometheus-sd.json",
+		Expands:     true,
+		Write:       writePrometheusSD,
+	},
+	"consul": {
+		Description: "Consul agent service definitions, one per host",
+		File:        "consul-services.json",
+		Expands:     true,
+		Write:       writeConsulServices,
+	},
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [CIDR...]",
+	Short: "Export ranges for firewalls and other tools",
+	Long: titleStyle.Render("Export Ranges") + "\n\n" +
+		"Write CIDR ranges in a format another tool can consume.\n" +
+		"Uses the CIDRs given as arguments, the --group, or every group in the config.\n\n" +
+		"Formats:\n" + exportFormatHelp(),
+	Example: `  cidr export --format nftables > /etc/nftables.d/cidr.nft
+  cidr export --format sg-json --group office
+  cidr export --format nginx 10.0.0.0/8 192.168.0.0/16
+  cidr export --format prometheus-sd --group nodes --port 9100 -o /etc/prometheus/sd/nodes.json`,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "Output format ("+strings.Join(exportFormatNames(), ", ")+")")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of stdout")
+	exportCmd.Flags().IntVar(&exportPort, "port", 0, "Port for service discovery targets (overrides the group's port setting)")
+	exportCmd.Flags().BoolVar(&excludeDeprecated, "exclude-deprecated", false, "Leave out ranges marked deprecated in the config")
+	exportCmd.Flags().IntVar(&exportMaxTargets, "max-targets", 65536, "Maximum number of hosts service discovery formats may expand to")
+	exportCmd.MarkFlagRequired("format")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	exp, ok := exporters[exportFormat]
+	if !ok {
+		return fmt.Errorf("unknown export format '%s' (use %s)", exportFormat, strings.Join(exportFormatNames(), ", "))
+	}
+
+	sets, err := collectExportSets(args)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := exp.Write(&buf, sets); err != nil {
+		return err
+	}
+
+	if exportOutput == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(exportOutput, buf.Bytes(), 0o644)
+}
+
+func exportFormatNames() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func exportFormatHelp() string {
+	var b strings.Builder
+	for _, name := range exportFormatNames() {
+		fmt.Fprintf(&b, "  %-14s %s\n", name, exporters[name].Description)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// collectExportSets builds the sets to export from CLI arguments, the
+// selected --group, or every group in the config.
+func collectExportSets(args []string) ([]exportSet, error) {
+	if len(args) > 0 {
+		set, err := newExportSet("cidr", args)
+		if err != nil {
+			return nil, err
+		}
+		return []exportSet{set}, nil
+	}
+
+	if configGroup != "" {
+		if cfg, err := loadConfig(); err == nil && cfg.hasGroup(configGroup) {
+			set, err := cfg.groupExportSet(configGroup)
+			if err != nil {
+				return nil, err
+			}
+			return []exportSet{set}, nil
+		}
+		cidrs, _, err := loadConfigCIDRs()
+		if err != nil {
+			return nil, err
+		}
+		set, err := newExportSet(configGroup, cidrs)
+		if err != nil {
+			return nil, err
+		}
+		return []exportSet{set}, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("no CIDR provided and could not load config file: %w", err)
+	}
+	return cfg.exportSets()
+}
+
+// exportSets returns one set per config group, preceded by a "default" set
+// for ungrouped ranges when there are any.
+func (c *cidrConfig) exportSets() ([]exportSet, error) {
+	var sets []exportSet
+
+	ungrouped, err := c.resolveEntries(func(entry configEntry) bool { return entry.Group == "" })
+	if err != nil {
+		return nil, err
+	}
+	if len(ungrouped) > 0 {
+		set, err := newExportSet("default", ungrouped)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+
+	for _, group := range c.Groups {
+		set, err := c.groupExportSet(group)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+
+	return sets, nil
+}
+
+// groupExportSet returns a config group as a single set with its settings.
+func (c *cidrConfig) groupExportSet(group string) (exportSet, error) {
+	cidrs, err := c.groupCIDRs(group)
+	if err != nil {
+		return exportSet{}, err
+	}
+	set, err := newExportSet(group, cidrs)
+	if err != nil {
+		return exportSet{}, err
+	}
+
+	prefix := "group." + group + "."
+	for key, value := range c.Values {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			set.Meta[name] = value
+		}
+	}
+
+	return set, nil
+}
+
+// newExportSet parses the ranges of a set into canonical order so that
+// exports are deterministic.
+func newExportSet(name string, cidrs []string) (exportSet, error) {
+	list, err := canonicalCIDRList(cidrs)
+	if err != nil {
+		return exportSet{}, fmt.Errorf("set '%s': %w", name, err)
+	}
+	return exportSet{Name: name, CIDRs: list, Meta: make(map[string]string)}, nil
+}
+
+// splitFamilies separates IPv4 and IPv6 ranges.
+func splitFamilies(cidrs []*net.IPNet) (v4, v6 []*net.IPNet) {
+	for _, ipnet := range cidrs {
+		if ipnet.IP.To4() != nil {
+			v4 = append(v4, ipnet)
+		} else {
+			v6 = append(v6, ipnet)
+		}
+	}
+	return v4, v6
+}
+
+func joinCIDRs(cidrs []*net.IPNet, sep string) string {
+	parts := make([]string, len(cidrs))
+	for i, ipnet := range cidrs {
+		parts[i] = ipnet.String()
+	}
+	return strings.Join(parts, sep)
+}
+
+func writeNftables(w io.Writer, sets []exportSet) error {
+	fmt.Fprintln(w, "# Generated by cidr - do not edit")
+	// Different groups can map to the same nft name ("a-b" and "a_b" both
+	// become a_b), which would define the same set twice
+	names := make(map[string]string)
+	for _, set := range sets {
+		name := nftSetName(set.Name)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("sets '%s' and '%s' both map to the nftables name '%s'; rename one of the groups", other, set.Name, name)
+		}
+		names[name] = set.Name
+	}
+
+	fmt.Fprintln(w, "table inet cidr {")
+	first := true
+	for _, set := range sets {
+		name := nftSetName(set.Name)
+		v4, v6 := splitFamilies(set.CIDRs)
+		families := []struct {
+			suffix, addrType string
+			cidrs            []*net.IPNet
+		}{
+			{"v4", "ipv4_addr", v4},
+			{"v6", "ipv6_addr", v6},
+		}
+		for _, family := range families {
+			// nftables rejects empty element lists
+			if len(family.cidrs) == 0 {
+				continue
+			}
+			if !first {
+				fmt.Fprintln(w)
+			}
+			first = false
+			fmt.Fprintf(w, "\tset %s_%s {\n", name, family.suffix)
+			fmt.Fprintf(w, "\t\ttype %s\n", family.addrType)
+			fmt.Fprintln(w, "\t\tflags interval")
+			fmt.Fprintln(w, "\t\tauto-merge")
+			fmt.Fprintf(w, "\t\telements = { %s }\n", joinCIDRs(family.cidrs, ",\n\t\t\t     "))
+			fmt.Fprintln(w, "\t}")
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+// nftSetName turns a group name into an nft identifier: letters, digits
+// and underscores, starting with a letter. Names that would start with
+// anything else get a "g_" prefix.
+func nftSetName(group string) string {
+	name := setNamePattern.ReplaceAllString(group, "_")
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "g_" + name
+	}
+	return name
+}
+
+type sgIPRange struct {
+	CidrIP      string `json:"CidrIp"`
+	Description string `json:"Description"`
+}
+
+type sgIPv6Range struct {
+	CidrIPv6    string `json:"CidrIpv6"`
+	Description string `json:"Description"`
+}
+
+type sgPermission struct {
+	IPProtocol string        `json:"IpProtocol"`
+	IPRanges   []sgIPRange   `json:"IpRanges"`
+	IPv6Ranges []sgIPv6Range `json:"Ipv6Ranges"`
+}
+
+// writeSecurityGroupJSON writes a single all-traffic permission suitable for
+// `aws ec2 authorize-security-group-ingress --ip-permissions file://...`.
+func writeSecurityGroupJSON(w io.Writer, sets []exportSet) error {
+	perm := sgPermission{IPProtocol: "-1", IPRanges: []sgIPRange{}, IPv6Ranges: []sgIPv6Range{}}
+	for _, set := range sets {
+		for _, ipnet := range set.CIDRs {
+			if ipnet.IP.To4() != nil {
+				perm.IPRanges = append(perm.IPRanges, sgIPRange{CidrIP: ipnet.String(), Description: set.Name})
+			} else {
+				perm.IPv6Ranges = append(perm.IPv6Ranges, sgIPv6Range{CidrIPv6: ipnet.String(), Description: set.Name})
+			}
+		}
+	}
+
+	out, err := json.MarshalIndent([]sgPermission{perm}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+func writeNginx(w io.Writer, sets []exportSet) error {
+	fmt.Fprintln(w, "# Generated by cidr - do not edit")
+	for i, set := range sets {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %s\n", set.Name)
+		for _, ipnet := range set.CIDRs {
+			fmt.Fprintf(w, "allow %s;\n", ipnet.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Service discovery exporters expand each set into one target per host.
+// Group settings supply the port and labels:
+//
+//	[group "nodes"]
+//	port = 9100
+//	label.env = prod
+//	service = node-exporter   # Consul service name, defaults to the group name
+//	10.20.0.0/24
+
+var consulNamePattern = regexp.MustCompile(`[^a-z0-9-]+`)
+
+type prometheusTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+func writePrometheusSD(w io.Writer, sets []exportSet) error {
+	hosts, err := expandSets(sets)
+	if err != nil {
+		return err
+	}
+
+	groups := []prometheusTargetGroup{}
+	for i, set := range sets {
+		port, err := setPort(set)
+		if err != nil {
+			return err
+		}
+
+		group := prometheusTargetGroup{Targets: []string{}, Labels: setLabels(set)}
+		for _, host := range hosts[i] {
+			if port > 0 {
+				group.Targets = append(group.Targets, net.JoinHostPort(host.String(), strconv.Itoa(port)))
+			} else {
+				group.Targets = append(group.Targets, host.String())
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	out, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+type consulService struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Address string            `json:"address"`
+	Port    int               `json:"port,omitempty"`
+	Meta    map[string]string `json:"meta"`
+}
+
+// writeConsulServices writes a services file for a Consul agent's config
+// directory, registering each host as an instance of the group's service.
+func writeConsulServices(w io.Writer, sets []exportSet) error {
+	hosts, err := expandSets(sets)
+	if err != nil {
+		return err
+	}
+
+	services := []consulService{}
+	for i, set := range sets {
+		port, err := setPort(set)
+		if err != nil {
+			return err
+		}
+
+		name := set.Meta["service"]
+		if name == "" {
+			name = set.Name
+		}
+		name = strings.Trim(consulNamePattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+
+		for _, host := range hosts[i] {
+			id := name + "-" + strings.NewReplacer(".", "-", ":", "-").Replace(host.String())
+			services = append(services, consulService{
+				ID:      id,
+				Name:    name,
+				Address: host.String(),
+				Port:    port,
+				Meta:    setLabels(set),
+			})
+		}
+	}
+
+	out, err := json.MarshalIndent(map[string][]consulService{"services": services}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+// setPort returns the --port flag, or the set's port setting, or 0.
+func setPort(set exportSet) (int, error) {
+	if exportPort > 0 {
+		return exportPort, nil
+	}
+	value := set.Meta["port"]
+	if value == "" {
+		return 0, nil
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("set '%s': invalid port '%s'", set.Name, value)
+	}
+	return port, nil
+}
+
+// setLabels returns the set's label.* settings plus a "group" label, with
+// names sanitized to [a-zA-Z0-9_].
+func setLabels(set exportSet) map[string]string {
+	labels := map[string]string{"group": set.Name}
+	for key, value := range set.Meta {
+		if name, ok := strings.CutPrefix(key, "label."); ok {
+			labels[setNamePattern.ReplaceAllString(name, "_")] = value
+		}
+	}
+	return labels
+}
+
+// expandSets lists the host addresses of every set, refusing to expand more
+// than --max-targets hosts in total.
+func expandSets(sets []exportSet) ([][]net.IP, error) {
+	total := new(big.Int)
+	for _, set := range sets {
+		for _, ipnet := range set.CIDRs {
+			total.Add(total, hostCount(ipnet))
+		}
+	}
+	if total.Cmp(big.NewInt(int64(exportMaxTargets))) > 0 {
+		return nil, fmt.Errorf("ranges expand to %s hosts, more than --max-targets %d", total, exportMaxTargets)
+	}
+
+	hosts := make([][]net.IP, len(sets))
+	for i, set := range sets {
+		seen := make(map[string]bool)
+		for _, ipnet := range set.CIDRs {
+			for _, host := range expandHosts(ipnet) {
+				if !seen[host.String()] {
+					seen[host.String()] = true
+					hosts[i] = append(hosts[i], host)
+				}
+			}
+		}
+		sort.Slice(hosts[i], func(a, b int) bool { return compareIPs(hosts[i][a], hosts[i][b]) < 0 })
+	}
+	return hosts, nil
+}
+
+// hostCount is the number of addresses expandHosts returns for a range.
+func hostCount(ipnet *net.IPNet) *big.Int {
+	ones, bits := ipnet.Mask.Size()
+	count := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	if bits == 32 && bits-ones >= 2 {
+		count.Sub(count, big.NewInt(2))
+	}
+	return count
+}
+
+// hostBounds returns the first and last usable addresses of a range: IPv4
+// networks of /30 and larger skip the network and broadcast addresses.
+func hostBounds(ipnet *net.IPNet) (first, last net.IP) {
+	if ones, bits := ipnet.Mask.Size(); bits == 32 && bits-ones >= 2 {
+		return getFirstUsableIP(ipnet), getLastUsableIP(ipnet)
+	}
+	return ipnet.IP, getBroadcastIP(ipnet)
+}
+
+// expandHosts lists the usable addresses of a range (see hostBounds).
+func expandHosts(ipnet *net.IPNet) []net.IP {
+	first, last := hostBounds(ipnet)
+
+	var hosts []net.IP
+	for ip := first; ; ip = nextIP(ip) {
+		hosts = append(hosts, ip)
+		if ip.Equal(last) {
+			break
+		}
+	}
+	return hosts
+}
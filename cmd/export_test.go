@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteNftables(t *testing.T) {
+	mustSets := func(groups ...[]string) []exportSet {
+		t.Helper()
+		var sets []exportSet
+		for _, g := range groups {
+			set, err := newExportSet(g[0], g[1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			sets = append(sets, set)
+		}
+		return sets
+	}
+
+	tests := []struct {
+		name    string
+		sets    []exportSet
+		want    []string // set lines, in order
+		wantErr string
+	}{
+		{
+			name: "names",
+			sets: mustSets([]string{"office", "10.0.0.0/8"}, []string{"a-b.c", "2001:db8::/32"}),
+			want: []string{"set office_v4 {", "set a_b_c_v6 {"},
+		},
+		{
+			name: "leading digit or separator",
+			sets: mustSets([]string{"1x", "10.0.0.0/8"}, []string{"-vpn", "10.1.0.0/16", "2001:db8::/32"}, []string{"_x", "10.2.0.0/16"}),
+			want: []string{"set g_1x_v4 {", "set g__vpn_v4 {", "set g__vpn_v6 {", "set g__x_v4 {"},
+		},
+		{
+			name: "empty sets are left out",
+			sets: mustSets([]string{"none"}, []string{"v6", "2001:db8::/32"}),
+			want: []string{"set v6_v6 {"},
+		},
+		{
+			name:    "collision",
+			sets:    mustSets([]string{"a-b", "10.0.0.0/8"}, []string{"a_b", "10.1.0.0/16"}),
+			wantErr: "sets 'a-b' and 'a_b' both map to the nftables name 'a_b'",
+		},
+		{
+			name:    "collision after prefixing",
+			sets:    mustSets([]string{"1x", "10.0.0.0/8"}, []string{"g_1x", "10.1.0.0/16"}),
+			wantErr: "sets '1x' and 'g_1x' both map to the nftables name 'g_1x'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeNftables(&buf, tt.sets)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("writeNftables: %v", err)
+			}
+
+			out := buf.String()
+			var got []string
+			for _, line := range strings.Split(out, "\n") {
+				if line = strings.TrimSpace(line); strings.HasPrefix(line, "set ") {
+					got = append(got, line)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("sets:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+
+			// Sets are separated by one blank line, with none after the
+			// table header
+			if !strings.HasPrefix(out, "# Generated by cidr - do not edit\ntable inet cidr {\n\tset ") {
+				t.Errorf("unexpected start of output:\n%s", out)
+			}
+			if n := strings.Count(out, "\n\n"); n != len(tt.want)-1 {
+				t.Errorf("%d blank lines, want %d:\n%s", n, len(tt.want)-1, out)
+			}
+		})
+	}
+}
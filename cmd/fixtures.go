@@ -0,0 +1,459 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fixturesFamily    string
+	fixturesCount     int
+	fixturesEdgeCases bool
+	fixturesFormat    string
+	fixturesSeed      uint64
+	fixturesOutput    string
+)
+
+var fixturesCmd = &cobra.Command{
+	Use:   "fixtures",
+	Short: "Generate test data of addresses and prefixes with expected properties",
+	Long: titleStyle.Render("Test Fixtures") + "\n\n" +
+		"Generate a JSON or YAML corpus of addresses and prefixes together with their\n" +
+		"computed properties (network, bounds, usable range, address counts, integer,\n" +
+		"PTR name, class), for use as golden test data in other projects.\n\n" +
+		"--count random cases are generated from --seed (random when not set; the seed\n" +
+		"is recorded in the output). --include-edge-cases adds a fixed list of tricky\n" +
+		"inputs: broadcasts, /31s, /127s, IPv4-mapped addresses, zone IDs, boundary\n" +
+		"addresses and inputs that must be rejected.",
+	Example: `  cidr fixtures --family both --count 50 --include-edge-cases
+  cidr fixtures --family 6 --count 200 --seed 42 --format yaml -o ipv6.yaml
+  cidr fixtures --count 0 --include-edge-cases`,
+	Args: cobra.NoArgs,
+	RunE: runFixtures,
+}
+
+func init() {
+	fixturesCmd.Flags().StringVar(&fixturesFamily, "family", "both", "Address family: 4, 6 or both")
+	fixturesCmd.Flags().IntVarP(&fixturesCount, "count", "n", 50, "Number of random fixtures")
+	fixturesCmd.Flags().BoolVar(&fixturesEdgeCases, "include-edge-cases", false, "Add the fixed list of edge cases")
+	fixturesCmd.Flags().StringVar(&fixturesFormat, "format", "json", "Output format: json or yaml")
+	fixturesCmd.Flags().Uint64Var(&fixturesSeed, "seed", 0, "Random seed (random when not set)")
+	fixturesCmd.Flags().StringVarP(&fixturesOutput, "output", "o", "", "File to write to (default stdout)")
+	rootCmd.AddCommand(fixturesCmd)
+}
+
+// fixture is one test case. Inputs that fail to parse only have the first
+// fields set; properties not applicable to an address or family are omitted.
+type fixture struct {
+	Input       string `json:"input"`
+	Kind        string `json:"kind"` // "address" or "prefix"
+	Family      int    `json:"family"`
+	Description string `json:"description,omitempty"`
+	Valid       bool   `json:"valid"`
+
+	// Addresses
+	Address    string `json:"address,omitempty"` // canonical form (RFC 5952 for IPv6)
+	Zone       string `json:"zone,omitempty"`
+	MappedIPv4 string `json:"mapped_ipv4,omitempty"`
+	Integer    string `json:"integer,omitempty"` // decimal, network order
+	Hex        string `json:"hex,omitempty"`
+	PTR        string `json:"ptr,omitempty"`
+
+	// Prefixes
+	Network         string `json:"network,omitempty"`
+	PrefixLength    *int   `json:"prefix_length,omitempty"`
+	Aligned         *bool  `json:"aligned,omitempty"`
+	Netmask         string `json:"netmask,omitempty"`
+	Broadcast       string `json:"broadcast,omitempty"`
+	First           string `json:"first,omitempty"`
+	Last            string `json:"last,omitempty"`
+	FirstUsable     string `json:"first_usable,omitempty"`
+	LastUsable      string `json:"last_usable,omitempty"`
+	Addresses       string `json:"addresses,omitempty"` // decimal, may exceed 64 bits
+	UsableAddresses string `json:"usable_addresses,omitempty"`
+
+	Class string `json:"class,omitempty"`
+}
+
+// fixtureEdgeCases are the inputs added by --include-edge-cases.
+var fixtureEdgeCases = [][2]string{
+	{"0.0.0.0", "unspecified address"},
+	{"255.255.255.255", "limited broadcast, highest IPv4 address"},
+	{"127.0.0.1", "loopback"},
+	{"10.0.0.0", "network address of a private range"},
+	{"169.254.0.1", "link-local"},
+	{"100.64.0.1", "shared address space (CGNAT)"},
+	{"0.0.0.0/0", "whole IPv4 space"},
+	{"192.168.1.0/24", "common LAN prefix"},
+	{"192.168.1.77/24", "host bits set, network is 192.168.1.0/24"},
+	{"10.0.0.0/30", "smallest prefix with network and broadcast excluded"},
+	{"10.0.0.0/31", "point-to-point link (RFC 3021), both addresses usable"},
+	{"10.0.0.1/32", "single host"},
+	{"255.255.255.254/31", "last /31 of the IPv4 space"},
+	{"255.255.255.255/32", "limited broadcast as a host prefix"},
+	{"224.0.0.0/4", "multicast block"},
+	{"::", "unspecified address"},
+	{"::1", "loopback"},
+	{"2001:db8::1", "documentation address"},
+	{"2001:0DB8:0000:0000:0000:0000:0000:0001", "non-canonical spelling of 2001:db8::1"},
+	{"2001:db8:0:0:1:0:0:1", "ambiguous :: placement, canonical form compresses the first run"},
+	{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "highest IPv6 address"},
+	{"::ffff:192.0.2.1", "IPv4-mapped IPv6 address"},
+	{"64:ff9b::192.0.2.1", "NAT64 address with embedded IPv4 notation"},
+	{"fe80::1%eth0", "link-local address with zone ID"},
+	{"ff02::1", "all-nodes multicast"},
+	{"::/0", "whole IPv6 space"},
+	{"2001:db8::/32", "documentation prefix"},
+	{"2001:db8::1/64", "host bits set, network is 2001:db8::/64"},
+	{"2001:db8::/127", "point-to-point link (RFC 6164)"},
+	{"2001:db8::1/128", "single host"},
+	{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127", "last /127 of the IPv6 space"},
+	{"::ffff:0.0.0.0/96", "IPv4-mapped range"},
+	{"::ffff:10.0.0.0/104", "IPv4-mapped prefix covering 10.0.0.0/8"},
+	{"fe80::/10", "link-local block"},
+	{"256.0.0.1", "invalid: octet out of range"},
+	{"1.2.3", "invalid: too few octets"},
+	{"010.0.0.1", "invalid: leading zero, ambiguous octal"},
+	{"1.2.3.4/33", "invalid: prefix length out of range"},
+	{"1.2.3.4/", "invalid: missing prefix length"},
+	{"1:2:3:4:5:6:7:8:9", "invalid: too many groups"},
+	{"2001:db8::1::2", "invalid: more than one ::"},
+	{"::1/129", "invalid: prefix length out of range"},
+	{"fe80::1%", "invalid: empty zone ID"},
+	{"fe80::%eth0/64", "invalid: zone ID on a prefix"},
+}
+
+type fixtureDocument struct {
+	Seed     uint64    `json:"seed"`
+	Fixtures []fixture `json:"fixtures"`
+}
+
+func runFixtures(cmd *cobra.Command, args []string) error {
+	var families []int
+	switch fixturesFamily {
+	case "4":
+		families = []int{4}
+	case "6":
+		families = []int{6}
+	case "both":
+		families = []int{4, 6}
+	default:
+		return fmt.Errorf("invalid family '%s': use 4, 6 or both", fixturesFamily)
+	}
+	if fixturesFormat != "json" && fixturesFormat != "yaml" {
+		return fmt.Errorf("invalid format '%s': use json or yaml", fixturesFormat)
+	}
+	if fixturesCount < 0 {
+		return fmt.Errorf("--count must not be negative")
+	}
+	if !cmd.Flags().Changed("seed") {
+		fixturesSeed = uint64(time.Now().UnixNano())
+	}
+
+	doc := fixtureDocument{Seed: fixturesSeed, Fixtures: []fixture{}}
+	edgeCases := 0
+	if fixturesEdgeCases {
+		for _, edge := range fixtureEdgeCases {
+			f := newFixture(edge[0])
+			if !slices.Contains(families, f.Family) {
+				continue
+			}
+			f.Description = edge[1]
+			doc.Fixtures = append(doc.Fixtures, f)
+			edgeCases++
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(fixturesSeed, fixturesSeed))
+	for i := range fixturesCount {
+		// Alternate families so "both" is split evenly
+		doc.Fixtures = append(doc.Fixtures, newFixture(randomFixtureInput(rng, families[i%len(families)])))
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if fixturesFormat == "yaml" {
+		if data, err = jsonToYAML(data); err != nil {
+			return err
+		}
+	}
+
+	summary := fmt.Sprintf("%d fixtures (%d edge cases, seed %d)", len(doc.Fixtures), edgeCases, fixturesSeed)
+	if fixturesOutput == "" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, dimStyle.Render(summary))
+		return nil
+	}
+
+	if err := os.WriteFile(fixturesOutput, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s %s\n", successStyle.Render("✓"), valueStyle.Render(fixturesOutput), dimStyle.Render(summary))
+
+	return nil
+}
+
+// randomFixtureInput returns a random address or prefix of the family.
+// IPv6 addresses are drawn from global unicast space (2000::/3); prefixes
+// keep their host bits half of the time.
+func randomFixtureInput(rng *rand.Rand, family int) string {
+	b := make([]byte, 4)
+	if family == 6 {
+		b = make([]byte, 16)
+	}
+	for i := range b {
+		b[i] = byte(rng.UintN(256))
+	}
+	if family == 6 {
+		b[0] = 0x20 | b[0]&0x1f
+	}
+	addr, _ := netip.AddrFromSlice(b)
+
+	if rng.IntN(5) < 2 {
+		return addr.String()
+	}
+	prefix := netip.PrefixFrom(addr, rng.IntN(addr.BitLen()+1))
+	if rng.IntN(2) == 0 {
+		prefix = prefix.Masked()
+	}
+	return prefix.String()
+}
+
+// newFixture parses input and computes its expected properties. Usable
+// addresses follow RFC 3021 like ipcalc and export-sd (see hostBounds): IPv4
+// prefixes of /30 and larger exclude the network and broadcast addresses,
+// other prefixes use every address. The `cidr <CIDR>` summary differs here,
+// as getUsableHosts reports no usable hosts for a /31. Class is left out
+// for prefixes wider than one address, which may span several classes.
+func newFixture(input string) fixture {
+	f := fixture{Input: input, Kind: "address", Family: 4}
+	if strings.Contains(input, ":") {
+		f.Family = 6
+	}
+
+	if strings.Contains(input, "/") {
+		f.Kind = "prefix"
+		prefix, err := netip.ParsePrefix(input)
+		if err != nil {
+			return f
+		}
+		f.Valid = true
+
+		network := prefix.Masked()
+		bits, hostBits := prefix.Bits(), prefix.Addr().BitLen()-prefix.Bits()
+		aligned := network.Addr() == prefix.Addr()
+		f.Network = network.String()
+		f.PrefixLength = &bits
+		f.Aligned = &aligned
+
+		first := new(big.Int).SetBytes(network.Addr().AsSlice())
+		size := new(big.Int).Lsh(big.NewInt(1), uint(hostBits))
+		last := new(big.Int).Sub(new(big.Int).Add(first, size), big.NewInt(1))
+		firstUsable, lastUsable, usable := first, last, size
+		if f.Family == 4 {
+			f.Netmask = net.IP(net.CIDRMask(bits, 32)).String()
+			if hostBits >= 2 {
+				f.Broadcast = fixtureAddr(last, 4).String()
+				firstUsable = new(big.Int).Add(first, big.NewInt(1))
+				lastUsable = new(big.Int).Sub(last, big.NewInt(1))
+				usable = new(big.Int).Sub(size, big.NewInt(2))
+			}
+		}
+
+		width := len(network.Addr().AsSlice())
+		f.First = fixtureAddr(first, width).String()
+		f.Last = fixtureAddr(last, width).String()
+		f.FirstUsable = fixtureAddr(firstUsable, width).String()
+		f.LastUsable = fixtureAddr(lastUsable, width).String()
+		f.Addresses = size.String()
+		f.UsableAddresses = usable.String()
+		if hostBits == 0 {
+			f.Class = classifyIP(net.IP(network.Addr().AsSlice()))
+		}
+		return f
+	}
+
+	addr, err := netip.ParseAddr(input)
+	if err != nil {
+		return f
+	}
+	f.Valid = true
+	f.Address = addr.String()
+	f.Zone = addr.Zone()
+	if addr.Is4In6() {
+		f.MappedIPv4 = addr.Unmap().String()
+	}
+
+	ip := net.IP(addr.AsSlice())
+	f.Integer = new(big.Int).SetBytes(ip).String()
+	f.Hex = fmt.Sprintf("0x%x", []byte(ip))
+	f.PTR = ptrName(ip)
+	f.Class = classifyIP(ip)
+	return f
+}
+
+// fixtureAddr converts an integer back to an address of width bytes,
+// keeping IPv4-mapped addresses in IPv6 form.
+func fixtureAddr(n *big.Int, width int) netip.Addr {
+	addr, _ := netip.AddrFromSlice(n.FillBytes(make([]byte, width)))
+	return addr
+}
+
+// jsonToYAML rewrites JSON as block-style YAML, keeping key order. Strings
+// are always double-quoted, so values such as "::1", "010" or "yes" keep
+// their type in every YAML parser.
+func jsonToYAML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	y := &yamlWriter{dec: dec}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		err = y.mapping(0, "")
+	case json.Delim('['):
+		err = y.sequence(0, "")
+	default:
+		y.buf.WriteString(yamlScalar(tok) + "\n")
+	}
+	return y.buf.Bytes(), err
+}
+
+type yamlWriter struct {
+	dec *json.Decoder
+	buf bytes.Buffer
+}
+
+// mapping writes the entries of an object whose '{' has been read. The
+// first line starts with first (e.g. "- " inside a sequence), later lines
+// are indented by indent spaces.
+func (y *yamlWriter) mapping(indent int, first string) error {
+	if !y.dec.More() {
+		y.buf.WriteString(first + "{}\n")
+		_, err := y.dec.Token()
+		return err
+	}
+
+	prefix := first
+	for y.dec.More() {
+		tok, err := y.dec.Token()
+		if err != nil {
+			return err
+		}
+		y.buf.WriteString(prefix + yamlKey(tok.(string)) + ":")
+		prefix = strings.Repeat(" ", indent)
+
+		if tok, err = y.dec.Token(); err != nil {
+			return err
+		}
+		if err := y.nested(tok, indent+2); err != nil {
+			return err
+		}
+	}
+	_, err := y.dec.Token()
+	return err
+}
+
+// sequence writes the elements of an array whose '[' has been read.
+func (y *yamlWriter) sequence(indent int, first string) error {
+	if !y.dec.More() {
+		y.buf.WriteString(first + "[]\n")
+		_, err := y.dec.Token()
+		return err
+	}
+
+	prefix := first
+	for y.dec.More() {
+		tok, err := y.dec.Token()
+		if err != nil {
+			return err
+		}
+		item := prefix + "- "
+		prefix = strings.Repeat(" ", indent)
+
+		switch tok {
+		case json.Delim('{'):
+			err = y.mapping(indent+2, item)
+		case json.Delim('['):
+			err = y.sequence(indent+2, item)
+		default:
+			y.buf.WriteString(item + yamlScalar(tok) + "\n")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := y.dec.Token()
+	return err
+}
+
+// nested writes a mapping value after its "key:".
+func (y *yamlWriter) nested(tok json.Token, indent int) error {
+	switch tok {
+	case json.Delim('{'), json.Delim('['):
+		first := " " // Empty containers stay on the key's line
+		if y.dec.More() {
+			first = "\n" + strings.Repeat(" ", indent)
+		}
+		if tok == json.Delim('{') {
+			return y.mapping(indent, first)
+		}
+		return y.sequence(indent, first)
+	}
+	y.buf.WriteString(" " + yamlScalar(tok) + "\n")
+	return nil
+}
+
+func yamlScalar(tok json.Token) string {
+	switch v := tok.(type) {
+	case string:
+		return yamlQuote(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	}
+	return "null"
+}
+
+// yamlKey leaves simple keys unquoted. Keys that a YAML reader would take
+// for a number, boolean or null are quoted so that they stay strings.
+func yamlKey(key string) string {
+	if key == "" || strings.TrimLeft(key, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") != "" ||
+		strings.IndexAny(key[:1], "0123456789-") == 0 || yamlReservedWords[strings.ToLower(key)] {
+		return yamlQuote(key)
+	}
+	return key
+}
+
+// yamlReservedWords are the plain scalars YAML 1.1 and 1.2 readers treat as
+// booleans or null.
+var yamlReservedWords = map[string]bool{
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true,
+}
+
+// yamlQuote returns a double-quoted YAML string. Go's escape sequences
+// (\n, \t, \", \\, \xNN, \uNNNN) are all valid in YAML double quotes.
+func yamlQuote(s string) string {
+	return fmt.Sprintf("%q", s)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestJSONToYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			name: "scalars",
+			json: `{"cidr": "10.0.0.0/8", "hosts": 16777216, "ratio": 0.5, "ipv6": false, "note": null}`,
+			want: "cidr: \"10.0.0.0/8\"\nhosts: 16777216\nratio: 0.5\nipv6: false\nnote: null\n",
+		},
+		{
+			name: "strings that look like other types",
+			json: `["123", "0x1f", "1e3", "true", "no", "null", "~", "", ".inf"]`,
+			want: "- \"123\"\n- \"0x1f\"\n- \"1e3\"\n- \"true\"\n- \"no\"\n- \"null\"\n- \"~\"\n- \"\"\n- \".inf\"\n",
+		},
+		{
+			name: "special characters",
+			json: `{"a": "key: value", "b": "# not a comment", "c": "  leading", "d": "two\nlines", "e": "tab\tand \"quotes\" \\", "f": "- item", "g": "é ✓"}`,
+			want: "a: \"key: value\"\nb: \"# not a comment\"\nc: \"  leading\"\nd: \"two\\nlines\"\n" +
+				"e: \"tab\\tand \\\"quotes\\\" \\\\\"\nf: \"- item\"\ng: \"é ✓\"\n",
+		},
+		{
+			name: "keys",
+			json: `{"plain_key-1": 1, "true": 2, "Null": 3, "8080": 4, "-x": 5, "a b": 6, "a:b": 7, "": 8, "#": 9}`,
+			want: "plain_key-1: 1\n\"true\": 2\n\"Null\": 3\n\"8080\": 4\n\"-x\": 5\n\"a b\": 6\n\"a:b\": 7\n\"\": 8\n\"#\": 9\n",
+		},
+		{
+			name: "empty containers",
+			json: `{"meta": {}, "cidrs": [], "nested": [[], {}], "deep": {"list": []}}`,
+			want: "meta: {}\ncidrs: []\nnested:\n  - []\n  - {}\ndeep:\n  list: []\n",
+		},
+		{
+			name: "empty top level",
+			json: `{}`,
+			want: "{}\n",
+		},
+		{
+			name: "nesting",
+			json: `{"sets": [{"name": "office", "cidrs": ["10.0.0.0/8", "192.168.0.0/16"], "meta": {"port": "443"}}, [1, [2, 3]]]}`,
+			want: "sets:\n" +
+				"  - name: \"office\"\n" +
+				"    cidrs:\n" +
+				"      - \"10.0.0.0/8\"\n" +
+				"      - \"192.168.0.0/16\"\n" +
+				"    meta:\n" +
+				"      port: \"443\"\n" +
+				"  - - 1\n" +
+				"    - - 2\n" +
+				"      - 3\n",
+		},
+		{
+			name: "top level scalar",
+			json: `"10.0.0.0/8"`,
+			want: "\"10.0.0.0/8\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonToYAML([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("jsonToYAML: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("jsonToYAML:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONToYAMLErrors(t *testing.T) {
+	for _, data := range []string{"", `{"a": }`, `[1, 2`, `{"a": [1}`} {
+		if got, err := jsonToYAML([]byte(data)); err == nil {
+			t.Errorf("jsonToYAML(%q) = %q, want an error", data, strings.TrimSpace(string(got)))
+		}
+	}
+}
+
+func TestNewFixturePrefixes(t *testing.T) {
+	tests := []struct {
+		input       string
+		firstUsable string
+		lastUsable  string
+		usable      string
+		class       string
+	}{
+		{input: "192.168.1.0/24", firstUsable: "192.168.1.1", lastUsable: "192.168.1.254", usable: "254"},
+		{input: "10.0.0.0/30", firstUsable: "10.0.0.1", lastUsable: "10.0.0.2", usable: "2"},
+		{input: "10.0.0.0/31", firstUsable: "10.0.0.0", lastUsable: "10.0.0.1", usable: "2"},
+		{input: "10.0.0.7/32", firstUsable: "10.0.0.7", lastUsable: "10.0.0.7", usable: "1", class: classifyIP(net.ParseIP("10.0.0.7"))},
+		{input: "0.0.0.0/0", firstUsable: "0.0.0.1", lastUsable: "255.255.255.254", usable: "4294967294"},
+		{input: "2001:db8::/127", firstUsable: "2001:db8::", lastUsable: "2001:db8::1", usable: "2"},
+		{input: "::1/128", firstUsable: "::1", lastUsable: "::1", usable: "1", class: classifyIP(net.ParseIP("::1"))},
+		{input: "::/0", firstUsable: "::", lastUsable: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", usable: "340282366920938463463374607431768211456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			f := newFixture(tt.input)
+			if !f.Valid {
+				t.Fatalf("%s is not valid", tt.input)
+			}
+			if f.FirstUsable != tt.firstUsable || f.LastUsable != tt.lastUsable || f.UsableAddresses != tt.usable {
+				t.Errorf("usable = %s - %s (%s), want %s - %s (%s)", f.FirstUsable, f.LastUsable, f.UsableAddresses,
+					tt.firstUsable, tt.lastUsable, tt.usable)
+			}
+			if f.Class != tt.class {
+				t.Errorf("class = %q, want %q", f.Class, tt.class)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ipcalcCheck     bool
+	ipcalcIPv4      bool
+	ipcalcIPv6      bool
+	ipcalcBroadcast bool
+	ipcalcNetmask   bool
+	ipcalcNetwork   bool
+	ipcalcPrefix    bool
+	ipcalcHostname  bool
+	ipcalcMinAddr   bool
+	ipcalcMaxAddr   bool
+	ipcalcAddresses bool
+	ipcalcSilent    bool
+)
+
+// ipcalcCmd follows the Red Hat ipcalc used by initscripts and many
+// provisioning scripts: each selected value is printed as a shell variable
+// assignment, e.g. `eval "$(ipcalc -n -p 10.1.2.3/24)"`.
+var ipcalcCmd = &cobra.Command{
+	Use:   "ipcalc [options] ADDRESS[/PREFIX] [NETMASK]",
+	Short: "ipcalc-compatible address calculator",
+	Long: "Calculate network values for an address, compatible with Red Hat's ipcalc.\n\n" +
+		"The prefix may be given as /PREFIX, /NETMASK or a separate NETMASK argument.\n" +
+		"Without one, IPv4 addresses get their classful mask and IPv6 addresses /128.\n" +
+		"Selected values are printed as NAME=value lines for use with eval; without\n" +
+		"any selection a summary is printed.",
+	Example: `  ipcalc -n -b 192.168.1.10/24
+  ipcalc -p 10.1.2.3 255.255.0.0
+  ipcalc -c -s "$addr" || echo invalid`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runIpcalc,
+}
+
+func init() {
+	flags := ipcalcCmd.Flags()
+	// -h is --hostname in ipcalc, so help is long-only
+	flags.Bool("help", false, "Show this help")
+	flags.BoolVarP(&ipcalcCheck, "check", "c", false, "Validate the address and exit")
+	flags.BoolVarP(&ipcalcIPv4, "ipv4", "4", false, "Require an IPv4 address")
+	flags.BoolVarP(&ipcalcIPv6, "ipv6", "6", false, "Require an IPv6 address")
+	flags.BoolVarP(&ipcalcBroadcast, "broadcast", "b", false, "Display the broadcast address (IPv4)")
+	flags.BoolVarP(&ipcalcNetmask, "netmask", "m", false, "Display the netmask")
+	flags.BoolVarP(&ipcalcNetwork, "network", "n", false, "Display the network address")
+	flags.BoolVarP(&ipcalcPrefix, "prefix", "p", false, "Display the prefix length")
+	flags.BoolVarP(&ipcalcHostname, "hostname", "h", false, "Display the hostname from a reverse DNS lookup")
+	flags.BoolVar(&ipcalcMinAddr, "minaddr", false, "Display the first usable address")
+	flags.BoolVar(&ipcalcMaxAddr, "maxaddr", false, "Display the last usable address")
+	flags.BoolVar(&ipcalcAddresses, "addresses", false, "Display the number of usable addresses")
+	flags.BoolVarP(&ipcalcSilent, "silent", "s", false, "Don't print error messages")
+	registerMultiCall("ipcalc", ipcalcCmd)
+}
+
+func runIpcalc(cmd *cobra.Command, args []string) error {
+	ip, ipnet, err := parseIpcalcArgs(args)
+	if err == nil {
+		err = checkIpcalcFamily(ip, args[0])
+	}
+	if err != nil {
+		if ipcalcSilent {
+			os.Exit(1)
+		}
+		return err
+	}
+	if ipcalcCheck {
+		return nil
+	}
+
+	ones, bits := ipnet.Mask.Size()
+	first, last := hostBounds(ipnet)
+
+	var vars []string
+	if ipcalcNetmask {
+		vars = append(vars, "NETMASK="+net.IP(ipnet.Mask).String())
+	}
+	if ipcalcPrefix {
+		vars = append(vars, "PREFIX="+strconv.Itoa(ones))
+	}
+	if ipcalcBroadcast && bits == 32 {
+		vars = append(vars, "BROADCAST="+getBroadcastIP(ipnet).String())
+	}
+	if ipcalcNetwork {
+		vars = append(vars, "NETWORK="+ipnet.IP.String())
+	}
+	if ipcalcMinAddr {
+		vars = append(vars, "MINADDR="+first.String())
+	}
+	if ipcalcMaxAddr {
+		vars = append(vars, "MAXADDR="+last.String())
+	}
+	if ipcalcAddresses {
+		vars = append(vars, "ADDRESSES="+hostCount(ipnet).String())
+	}
+	if ipcalcHostname {
+		names, err := lookupHostname(ip)
+		if err != nil {
+			if ipcalcSilent {
+				os.Exit(1)
+			}
+			return err
+		}
+		vars = append(vars, "HOSTNAME="+strings.TrimSuffix(names[0], "."))
+	}
+
+	if len(vars) > 0 {
+		for _, v := range vars {
+			fmt.Println(v)
+		}
+		return nil
+	}
+
+	if ipcalcBroadcast {
+		// Only IPv6 addresses were asked for a broadcast address
+		return nil
+	}
+
+	fmt.Printf("Address:\t%s\n", ip)
+	fmt.Printf("Network:\t%s\n", ipnet)
+	fmt.Printf("Netmask:\t%s = %d\n", net.IP(ipnet.Mask), ones)
+	if bits == 32 {
+		fmt.Printf("Broadcast:\t%s\n", getBroadcastIP(ipnet))
+	}
+	fmt.Println()
+	fmt.Printf("Address space:\t%s\n", classifyIP(ip))
+	if bits == 32 {
+		fmt.Printf("Address class:\t%s\n", ipv4Class(ip))
+	}
+	fmt.Printf("HostMin:\t%s\n", first)
+	fmt.Printf("HostMax:\t%s\n", last)
+	fmt.Printf("Hosts/Net:\t%s\n", hostCount(ipnet))
+
+	return nil
+}
+
+// lookupHostname resolves an address's PTR record within the dns timeout.
+func lookupHostname(ip net.IP) ([]string, error) {
+	cfg, err := loadConfig()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	timeout, err := sourceTimeout(cfg, "dns")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip.String())
+	if ctx.Err() != nil {
+		return nil, timeoutError("dns", timeout, ctx.Err())
+	}
+	if err != nil || len(names) == 0 {
+		return nil, fmt.Errorf("cannot find hostname for %s", ip)
+	}
+	return names, nil
+}
+
+// parseIpcalcArgs accepts ADDRESS, ADDRESS/PREFIX, ADDRESS/NETMASK and
+// ADDRESS NETMASK. IPv4 addresses are returned in their 4-byte form.
+func parseIpcalcArgs(args []string) (net.IP, *net.IPNet, error) {
+	addr, mask, hasMask := strings.Cut(args[0], "/")
+	if len(args) == 2 {
+		if hasMask {
+			return nil, nil, fmt.Errorf("both a prefix and a netmask were given")
+		}
+		mask, hasMask = args[1], true
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		if strings.Contains(addr, ":") {
+			return nil, nil, fmt.Errorf("bad IPv6 address: %s", addr)
+		}
+		return nil, nil, fmt.Errorf("bad IPv4 address: %s", addr)
+	}
+	bits := 8 * net.IPv6len
+	if v4 := ip.To4(); v4 != nil && !strings.Contains(addr, ":") {
+		ip, bits = v4, 8*net.IPv4len
+	}
+
+	ones := bits
+	if hasMask {
+		if n, err := strconv.Atoi(mask); err == nil {
+			if n < 0 || n > bits {
+				return nil, nil, fmt.Errorf("bad prefix: %s", mask)
+			}
+			ones = n
+		} else {
+			m := net.ParseIP(mask).To4()
+			if m == nil || bits != 32 {
+				return nil, nil, fmt.Errorf("bad netmask: %s", mask)
+			}
+			var size int
+			if ones, size = net.IPMask(m).Size(); size == 0 {
+				return nil, nil, fmt.Errorf("bad netmask: %s", mask)
+			}
+		}
+	} else if bits == 32 {
+		ones = classfulPrefix(ip)
+	}
+
+	netmask := net.CIDRMask(ones, bits)
+	return ip, &net.IPNet{IP: ip.Mask(netmask), Mask: netmask}, nil
+}
+
+// checkIpcalcFamily rejects an address of the wrong family for -4 or -6.
+// IPv4-mapped addresses count as IPv6.
+func checkIpcalcFamily(ip net.IP, arg string) error {
+	if ipcalcIPv4 && len(ip) != net.IPv4len {
+		return fmt.Errorf("bad IPv4 address: %s", arg)
+	}
+	if ipcalcIPv6 && len(ip) == net.IPv4len {
+		return fmt.Errorf("bad IPv6 address: %s", arg)
+	}
+	return nil
+}
+
+// classfulPrefix is the pre-CIDR default prefix of an IPv4 address.
+func classfulPrefix(ip net.IP) int {
+	switch {
+	case ip[0] < 128:
+		return 8
+	case ip[0] < 192:
+		return 16
+	case ip[0] < 224:
+		return 24
+	}
+	return 32
+}
+
+func ipv4Class(ip net.IP) string {
+	switch {
+	case ip[0] < 128:
+		return "Class A"
+	case ip[0] < 192:
+		return "Class B"
+	case ip[0] < 224:
+		return "Class C"
+	case ip[0] < 240:
+		return "Class D, Multicast"
+	}
+	return "Class E, Reserved"
+}
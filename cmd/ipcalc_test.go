@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParseIpcalcArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		ip      string
+		ipLen   int
+		network string
+		wantErr string
+	}{
+		{name: "prefix", args: []string{"192.168.1.10/24"}, ip: "192.168.1.10", ipLen: 4, network: "192.168.1.0/24"},
+		{name: "netmask argument", args: []string{"10.1.2.3", "255.255.0.0"}, ip: "10.1.2.3", ipLen: 4, network: "10.1.0.0/16"},
+		{name: "netmask after slash", args: []string{"10.1.2.3/255.255.255.0"}, ip: "10.1.2.3", ipLen: 4, network: "10.1.2.0/24"},
+		{name: "prefix argument", args: []string{"10.1.2.3", "20"}, ip: "10.1.2.3", ipLen: 4, network: "10.1.0.0/20"},
+		{name: "zero prefix", args: []string{"10.1.2.3/0"}, ip: "10.1.2.3", ipLen: 4, network: "0.0.0.0/0"},
+		{name: "class A default", args: []string{"10.1.2.3"}, ip: "10.1.2.3", ipLen: 4, network: "10.0.0.0/8"},
+		{name: "class B default", args: []string{"172.16.5.4"}, ip: "172.16.5.4", ipLen: 4, network: "172.16.0.0/16"},
+		{name: "class C default", args: []string{"192.0.2.1"}, ip: "192.0.2.1", ipLen: 4, network: "192.0.2.0/24"},
+		{name: "multicast default", args: []string{"224.0.0.1"}, ip: "224.0.0.1", ipLen: 4, network: "224.0.0.1/32"},
+		{name: "IPv6 prefix", args: []string{"2001:db8::1/64"}, ip: "2001:db8::1", ipLen: 16, network: "2001:db8::/64"},
+		{name: "IPv6 default", args: []string{"2001:db8::1"}, ip: "2001:db8::1", ipLen: 16, network: "2001:db8::1/128"},
+		{name: "IPv4-mapped", args: []string{"::ffff:10.1.2.3/120"}, ip: "10.1.2.3", ipLen: 16, network: "10.1.2.0/24"},
+		{name: "prefix and netmask", args: []string{"10.1.2.3/24", "255.255.255.0"}, wantErr: "both a prefix and a netmask were given"},
+		{name: "bad IPv4 address", args: []string{"10.1.2/24"}, wantErr: "bad IPv4 address: 10.1.2"},
+		{name: "bad IPv6 address", args: []string{"2001:db8::g"}, wantErr: "bad IPv6 address: 2001:db8::g"},
+		{name: "prefix too long", args: []string{"10.0.0.0/33"}, wantErr: "bad prefix: 33"},
+		{name: "negative prefix", args: []string{"10.0.0.0/-1"}, wantErr: "bad prefix: -1"},
+		{name: "IPv6 prefix too long", args: []string{"2001:db8::/129"}, wantErr: "bad prefix: 129"},
+		{name: "non-contiguous netmask", args: []string{"10.0.0.0", "255.0.255.0"}, wantErr: "bad netmask: 255.0.255.0"},
+		{name: "IPv6 netmask", args: []string{"2001:db8::", "255.255.0.0"}, wantErr: "bad netmask: 255.255.0.0"},
+		{name: "garbage netmask", args: []string{"10.0.0.0/abc"}, wantErr: "bad netmask: abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, ipnet, err := parseIpcalcArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseIpcalcArgs: %v", err)
+			}
+			if ip.String() != tt.ip || len(ip) != tt.ipLen || ipnet.String() != tt.network {
+				t.Errorf("parseIpcalcArgs = %s (%d bytes), %s; want %s (%d bytes), %s", ip, len(ip), ipnet, tt.ip, tt.ipLen, tt.network)
+			}
+		})
+	}
+}
+
+func TestCheckIpcalcFamily(t *testing.T) {
+	oldIPv4, oldIPv6 := ipcalcIPv4, ipcalcIPv6
+	t.Cleanup(func() { ipcalcIPv4, ipcalcIPv6 = oldIPv4, oldIPv6 })
+
+	tests := []struct {
+		arg     string
+		ipv4    bool
+		ipv6    bool
+		wantErr string
+	}{
+		{arg: "10.0.0.1/24"},
+		{arg: "2001:db8::1"},
+		{arg: "10.0.0.1/24", ipv4: true},
+		{arg: "10.0.0.1/24", ipv6: true, wantErr: "bad IPv6 address: 10.0.0.1/24"},
+		{arg: "2001:db8::1", ipv6: true},
+		{arg: "2001:db8::1", ipv4: true, wantErr: "bad IPv4 address: 2001:db8::1"},
+		{arg: "::ffff:10.0.0.1", ipv6: true},
+		{arg: "::ffff:10.0.0.1", ipv4: true, wantErr: "bad IPv4 address: ::ffff:10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		ipcalcIPv4, ipcalcIPv6 = tt.ipv4, tt.ipv6
+		ip, _, err := parseIpcalcArgs([]string{tt.arg})
+		if err != nil {
+			t.Fatalf("parseIpcalcArgs(%s): %v", tt.arg, err)
+		}
+		err = checkIpcalcFamily(ip, tt.arg)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s (-4 %v, -6 %v): %v", tt.arg, tt.ipv4, tt.ipv6, err)
+		}
+		if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("%s (-4 %v, -6 %v): error = %v, want %q", tt.arg, tt.ipv4, tt.ipv6, err, tt.wantErr)
+		}
+	}
+}
+
+func TestClassfulPrefix(t *testing.T) {
+	for addr, want := range map[string]int{
+		"0.0.0.0":         8,
+		"10.1.2.3":        8,
+		"127.255.255.255": 8,
+		"128.0.0.0":       16,
+		"191.255.255.255": 16,
+		"192.0.0.0":       24,
+		"223.255.255.255": 24,
+		"224.0.0.0":       32,
+		"240.0.0.1":       32,
+		"255.255.255.255": 32,
+	} {
+		if got := classfulPrefix(net.ParseIP(addr).To4()); got != want {
+			t.Errorf("classfulPrefix(%s) = %d, want %d", addr, got, want)
+		}
+	}
+}
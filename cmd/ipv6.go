@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// interfaceID describes what the low 64 bits of an IPv6 address look like.
+type interfaceID struct {
+	Kind        string
+	Description string
+	MAC         net.HardwareAddr
+	IPv4        net.IP
+}
+
+// analyzeInterfaceID applies heuristics to the interface identifier of a
+// unicast IPv6 address. It returns false for IPv4 and multicast addresses.
+//
+// A single address cannot prove how its identifier was generated, so the
+// randomized kinds are best guesses: RFC 4941 temporary addresses clear the
+// universal/local bit, while RFC 7217 stable-privacy identifiers leave it
+// random. A set bit therefore rules out a classic temporary address.
+func analyzeInterfaceID(ip net.IP) (interfaceID, bool) {
+	if ip.To4() != nil || ip.IsMulticast() || ip.IsUnspecified() || ip.IsLoopback() {
+		return interfaceID{}, false
+	}
+	ip = ip.To16()
+	iid := ip[8:]
+
+	switch {
+	case iid[3] == 0xff && iid[4] == 0xfe:
+		mac := net.HardwareAddr{iid[0] ^ 0x02, iid[1], iid[2], iid[5], iid[6], iid[7]}
+		return interfaceID{
+			Kind:        "eui-64",
+			Description: "EUI-64 (derived from the interface MAC address)",
+			MAC:         mac,
+		}, true
+
+	case bytes.Equal(iid[1:4], []byte{0x00, 0x5e, 0xfe}) && (iid[0] == 0x00 || iid[0] == 0x02):
+		return interfaceID{
+			Kind:        "isatap",
+			Description: "ISATAP (embeds an IPv4 address)",
+			IPv4:        net.IPv4(iid[4], iid[5], iid[6], iid[7]),
+		}, true
+
+	case bytes.Equal(iid[:5], make([]byte, 5)):
+		return interfaceID{
+			Kind:        "low-byte",
+			Description: "Manually assigned (low-byte identifier)",
+		}, true
+
+	case iid[0]&0x02 == 0:
+		return interfaceID{
+			Kind:        "temporary",
+			Description: "Randomized, likely a temporary address (RFC 4941/8981) or stable-privacy (RFC 7217)",
+		}, true
+	}
+
+	return interfaceID{
+		Kind:        "stable-privacy",
+		Description: "Randomized, likely a stable-privacy opaque identifier (RFC 7217)",
+	}, true
+}
+
+func displayInterfaceID(ip net.IP) {
+	id, ok := analyzeInterfaceID(ip)
+	if !ok {
+		return
+	}
+
+	fmt.Printf("%s %s\n", labelStyle.Render("Interface ID:"), valueStyle.Render(id.Description))
+	if id.MAC != nil {
+		fmt.Printf("%s %s\n", labelStyle.Render("MAC Address:"), valueStyle.Render(id.MAC.String()))
+	}
+	if id.IPv4 != nil {
+		fmt.Printf("%s %s\n", labelStyle.Render("Embedded IPv4:"), valueStyle.Render(id.IPv4.String()))
+	}
+}
@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	leasesSource string
+	leasesFormat string
+)
+
+var leasesCmd = &cobra.Command{
+	Use:   "leases [CIDR...]",
+	Short: "Check DHCP leases against CIDR ranges",
+	Long: titleStyle.Render("DHCP Lease Check") + "\n\n" +
+		"Parse a DHCP lease file and map active leases onto CIDR ranges.\n" +
+		"Reports pool utilization per range and flags leases outside every range.\n" +
+		"Supports ISC dhcpd, Kea (memfile CSV) and dnsmasq lease files.\n" +
+		"Uses the ranges from the config file when no CIDR is given.",
+	Example: `  cidr leases --source /var/lib/dhcp/dhcpd.leases 192.168.1.0/24
+  cidr leases --source /var/lib/kea/kea-leases4.csv --format kea
+  cidr leases --source /var/lib/misc/dnsmasq.leases`,
+	RunE: runLeases,
+}
+
+func init() {
+	leasesCmd.Flags().StringVarP(&leasesSource, "source", "s", "", "Path to the DHCP lease file")
+	leasesCmd.Flags().StringVar(&leasesFormat, "format", "auto", "Lease file format: auto, isc, kea or dnsmasq")
+	leasesCmd.MarkFlagRequired("source")
+	rootCmd.AddCommand(leasesCmd)
+}
+
+// dhcpLease is a single lease as read from a lease file.
+type dhcpLease struct {
+	IP       net.IP
+	MAC      string
+	Hostname string
+	Active   bool
+}
+
+func runLeases(cmd *cobra.Command, args []string) error {
+	cidrs := args
+	var configPath string
+	if len(cidrs) == 0 {
+		configCIDRs, path, err := loadConfigCIDRs()
+		if err != nil {
+			return fmt.Errorf("no CIDR provided and could not load config file: %w", err)
+		}
+		cidrs = configCIDRs
+		configPath = path
+	}
+
+	var subnets []*net.IPNet
+	for _, cidrStr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidrStr)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR notation '%s': %w", cidrStr, err)
+		}
+		subnets = append(subnets, ipnet)
+	}
+
+	leases, err := loadLeases(leasesSource, leasesFormat, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if configPath != "" {
+		fmt.Println(dimStyle.Render(fmt.Sprintf("Using config from: %s", configPath)))
+		fmt.Println()
+	}
+
+	fmt.Println(titleStyle.Render("DHCP Lease Check"))
+	fmt.Printf("%s %s\n\n", labelStyle.Render("Lease File:"), valueStyle.Render(leasesSource))
+
+	active := 0
+	counts := make([]int, len(subnets))
+	var outside []dhcpLease
+	for _, lease := range leases {
+		if !lease.Active {
+			continue
+		}
+		active++
+		matched := false
+		for i, subnet := range subnets {
+			if subnet.Contains(lease.IP) {
+				counts[i]++
+				matched = true
+			}
+		}
+		if !matched {
+			outside = append(outside, lease)
+		}
+	}
+
+	fmt.Printf("%s %s\n", labelStyle.Render("Active Leases:"), valueStyle.Render(strconv.Itoa(active)))
+	fmt.Printf("%s %s\n", labelStyle.Render("Inactive Leases:"), valueStyle.Render(strconv.Itoa(len(leases)-active)))
+	fmt.Println()
+
+	for i, subnet := range subnets {
+		capacity := leaseCapacity(subnet)
+		utilization := 0.0
+		if capacity > 0 {
+			utilization = float64(counts[i]) / capacity * 100
+		}
+		fmt.Printf("%s %s %s\n",
+			labelStyle.Render(subnet.String()+":"),
+			valueStyle.Render(fmt.Sprintf("%d / %s", counts[i], formatCapacity(capacity))),
+			dimStyle.Render(fmt.Sprintf("(%.1f%% utilized)", utilization)))
+	}
+
+	fmt.Println()
+	if len(outside) == 0 {
+		fmt.Println(successStyle.Render("All active leases are within the declared ranges"))
+	} else {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%d active lease(s) outside the declared ranges:", len(outside))))
+		for _, lease := range outside {
+			detail := lease.MAC
+			if lease.Hostname != "" {
+				detail = strings.TrimSpace(detail + " " + lease.Hostname)
+			}
+			fmt.Printf("%s %s %s\n", errorStyle.Render("✗"), lease.IP.String(), dimStyle.Render(detail))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr leases --help' for more options"))
+
+	return nil
+}
+
+// loadLeases reads a lease file and returns one lease per address. When a
+// file records the same address several times the last record wins, which
+// matches how all three servers append lease updates.
+func loadLeases(path, format string, now time.Time) ([]dhcpLease, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "auto" {
+		if format = detectLeaseFormat(string(data)); format == "" {
+			return nil, fmt.Errorf("could not detect the format of lease file %s (use --format isc, kea or dnsmasq)", path)
+		}
+	}
+
+	var leases []dhcpLease
+	switch format {
+	case "isc":
+		leases, err = parseISCLeases(string(data), now)
+	case "kea":
+		leases, err = parseKeaLeases(string(data), now)
+	case "dnsmasq":
+		leases, err = parseDnsmasqLeases(string(data), now)
+	default:
+		return nil, fmt.Errorf("unknown lease format '%s' (use isc, kea or dnsmasq)", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s lease file %s: %w", format, path, err)
+	}
+
+	// Keep the latest record for each address
+	latest := make(map[string]int)
+	var unique []dhcpLease
+	for _, lease := range leases {
+		key := lease.IP.String()
+		if i, ok := latest[key]; ok {
+			unique[i] = lease
+			continue
+		}
+		latest[key] = len(unique)
+		unique = append(unique, lease)
+	}
+
+	sort.Slice(unique, func(i, j int) bool {
+		return compareIPs(unique[i].IP, unique[j].IP) < 0
+	})
+
+	return unique, nil
+}
+
+// detectLeaseFormat guesses the format from the first non-comment line. It
+// returns "" when the line matches no format; an empty file is read as an
+// empty dnsmasq lease file.
+func detectLeaseFormat(data string) string {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "address,"):
+			return "kea"
+		case strings.HasPrefix(line, "lease ") || strings.HasPrefix(line, "authoring-byte-order") ||
+			strings.HasPrefix(line, "server-duid"):
+			return "isc"
+		}
+		// dnsmasq lines start with the expiry time or a "duid" line
+		first := strings.Fields(line)[0]
+		if _, err := strconv.ParseInt(first, 10, 64); err == nil || first == "duid" {
+			return "dnsmasq"
+		}
+		return ""
+	}
+	return "dnsmasq"
+}
+
+// parseISCLeases parses the ISC dhcpd lease database. Only IPv4 "lease"
+// blocks are considered; a lease is active when its binding state is
+// active and it has not yet ended.
+func parseISCLeases(data string, now time.Time) ([]dhcpLease, error) {
+	var leases []dhcpLease
+	var current *dhcpLease
+	var state string
+	var ends time.Time
+	var endsNever bool
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if current == nil {
+			if strings.HasPrefix(line, "lease ") && strings.HasSuffix(line, "{") {
+				fields := strings.Fields(line)
+				ip := net.ParseIP(fields[1])
+				if ip == nil {
+					return nil, fmt.Errorf("line %d: invalid lease address '%s'", lineNum, fields[1])
+				}
+				current = &dhcpLease{IP: ip}
+				state, ends, endsNever = "", time.Time{}, false
+			}
+			continue
+		}
+
+		if line == "}" {
+			current.Active = state == "active" && (endsNever || ends.IsZero() || ends.After(now))
+			leases = append(leases, *current)
+			current = nil
+			continue
+		}
+
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		line = strings.TrimSuffix(line, ";")
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "binding state "):
+			state = fields[2]
+		case strings.HasPrefix(line, "ends "):
+			switch {
+			case len(fields) >= 2 && fields[1] == "never":
+				endsNever = true
+			case len(fields) >= 3 && fields[1] == "epoch":
+				secs, err := strconv.ParseInt(fields[2], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid lease end '%s'", lineNum, line)
+				}
+				ends = time.Unix(secs, 0)
+			case len(fields) >= 4:
+				t, err := time.Parse("2006/01/02 15:04:05", fields[2]+" "+fields[3])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid lease end '%s'", lineNum, line)
+				}
+				ends = t
+			}
+		case strings.HasPrefix(line, "hardware ethernet ") && len(fields) >= 3:
+			current.MAC = fields[2]
+		case strings.HasPrefix(line, "client-hostname ") && len(fields) >= 2:
+			current.Hostname = strings.Trim(fields[1], `"`)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, fmt.Errorf("unterminated lease block for %s", current.IP)
+	}
+
+	return leases, nil
+}
+
+// parseKeaLeases parses a Kea memfile lease CSV (lease4 or lease6). Columns
+// are located by header name; a lease is active when its state is 0
+// (default) and it has not yet expired.
+func parseKeaLeases(data string, now time.Time) ([]dhcpLease, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["address"]; !ok {
+		return nil, fmt.Errorf("missing 'address' column in header")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var leases []dhcpLease
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		addr := field(record, "address")
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid lease address '%s'", addr)
+		}
+
+		active := field(record, "state") == "" || field(record, "state") == "0"
+		if expire := field(record, "expire"); expire != "" {
+			secs, err := strconv.ParseInt(expire, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expire time '%s' for %s", expire, addr)
+			}
+			active = active && time.Unix(secs, 0).After(now)
+		}
+
+		leases = append(leases, dhcpLease{
+			IP:       ip,
+			MAC:      field(record, "hwaddr"),
+			Hostname: field(record, "hostname"),
+			Active:   active,
+		})
+	}
+
+	return leases, nil
+}
+
+// parseDnsmasqLeases parses a dnsmasq lease file. Each line holds the
+// expiry time, MAC (or IAID for IPv6), address, hostname and client ID; an
+// expiry of 0 means the lease never expires.
+func parseDnsmasqLeases(data string, now time.Time) ([]dhcpLease, error) {
+	var leases []dhcpLease
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "duid" {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 fields", lineNum)
+		}
+
+		expiry, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry time '%s'", lineNum, fields[0])
+		}
+		ip := net.ParseIP(fields[2])
+		if ip == nil {
+			return nil, fmt.Errorf("line %d: invalid lease address '%s'", lineNum, fields[2])
+		}
+
+		lease := dhcpLease{
+			IP:     ip,
+			Active: expiry == 0 || time.Unix(expiry, 0).After(now),
+		}
+		if ip.To4() != nil {
+			lease.MAC = fields[1]
+		}
+		if len(fields) >= 4 && fields[3] != "*" {
+			lease.Hostname = fields[3]
+		}
+		leases = append(leases, lease)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// leaseCapacity returns the number of leasable addresses in a subnet as a
+// float so that large IPv6 prefixes do not overflow.
+func leaseCapacity(ipnet *net.IPNet) float64 {
+	if ipnet.IP.To4() != nil {
+		return float64(getUsableHosts(ipnet))
+	}
+	ones, bits := ipnet.Mask.Size()
+	return math.Ldexp(1, bits-ones)
+}
+
+func formatCapacity(capacity float64) string {
+	if capacity < 1e15 {
+		return strconv.FormatFloat(capacity, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(capacity, 'e', 2, 64)
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leaseNow is the reference time for the samples: 2026-10-15 12:00 UTC.
+// Epoch 1792087200 is six hours later, 1792044000 six hours earlier.
+var leaseNow = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+const iscSample = `# The format of this file is documented in the dhcpd.leases(5) manual page.
+authoring-byte-order little-endian;
+
+lease 192.168.1.10 {
+  starts 4 2026/10/15 10:00:00;
+  ends 4 2026/10/15 22:00:00;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:55;
+  client-hostname "laptop";
+}
+lease 192.168.1.11 {
+  ends 4 2026/10/15 09:00:00;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:66;
+}
+lease 192.168.1.12 {
+  ends never;
+  binding state free;
+}
+lease 192.168.1.13 {
+  ends epoch 1792087200; # 2026/10/15 18:00:00 UTC
+  binding state active;
+}
+lease 192.168.1.14 {
+  ends never;
+  binding state active;
+}
+ia-na "\001\000\000\000" {
+  cltt 4 2026/10/15 10:00:00;
+  iaaddr 2001:db8::10 {
+    binding state active;
+  }
+}
+`
+
+const keaSample = `address,hwaddr,client_id,valid_lifetime,expire,subnet_id,fqdn_fwd,fqdn_rev,hostname,state
+10.0.0.10,00:11:22:33:44:55,,3600,1792087200,1,0,0,printer,0
+10.0.0.11,00:11:22:33:44:66,,3600,1792044000,1,0,0,old,0
+10.0.0.12,00:11:22:33:44:77,,3600,1792087200,1,0,0,,1
+`
+
+const kea6Sample = `address,duid,valid_lifetime,expire,subnet_id,pref_lifetime,lease_type,iaid,prefix_len,fqdn_fwd,fqdn_rev,hostname,hwaddr,state
+2001:db8::10,00:01:00:01:2a:bc:de:f0,3600,1792087200,1,1800,0,1,128,0,0,host6,00:11:22:33:44:55,0
+2001:db8::11,00:01:00:01:2a:bc:de:f1,3600,1792044000,1,1800,0,1,128,0,0,,,0
+`
+
+const dnsmasqSample = `1792087200 00:11:22:33:44:55 192.168.1.20 printer 01:00:11:22:33:44:55
+1792044000 00:11:22:33:44:66 192.168.1.21 * *
+0 00:11:22:33:44:77 192.168.1.22 static *
+duid 00:01:00:01:2a:bc:de:f0:00:11:22:33:44:55
+1792087200 1234567 2001:db8::20 host6 00:01:00:01:2a:bc:de:f1
+1792044000 1234568 2001:db8::21 * 00:01:00:01:2a:bc:de:f2
+`
+
+// leaseSummary formats a lease as "ip mac hostname active" for comparison.
+func leaseSummary(l dhcpLease) string {
+	state := "inactive"
+	if l.Active {
+		state = "active"
+	}
+	return strings.Join([]string{l.IP.String(), l.MAC, l.Hostname, state}, " ")
+}
+
+func TestParseLeases(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string, time.Time) ([]dhcpLease, error)
+		data  string
+		want  []string
+	}{
+		{
+			name:  "isc",
+			parse: parseISCLeases,
+			data:  iscSample,
+			want: []string{
+				"192.168.1.10 00:11:22:33:44:55 laptop active",
+				"192.168.1.11 00:11:22:33:44:66  inactive", // expired
+				"192.168.1.12   inactive",                  // free
+				"192.168.1.13   active",                    // epoch end
+				"192.168.1.14   active",                    // ends never
+				// IPv6 ia-na blocks are skipped
+			},
+		},
+		{
+			name:  "kea lease4",
+			parse: parseKeaLeases,
+			data:  keaSample,
+			want: []string{
+				"10.0.0.10 00:11:22:33:44:55 printer active",
+				"10.0.0.11 00:11:22:33:44:66 old inactive", // expired
+				"10.0.0.12 00:11:22:33:44:77  inactive",    // declined (state 1)
+			},
+		},
+		{
+			name:  "kea lease6",
+			parse: parseKeaLeases,
+			data:  kea6Sample,
+			want: []string{
+				"2001:db8::10 00:11:22:33:44:55 host6 active",
+				"2001:db8::11   inactive",
+			},
+		},
+		{
+			name:  "dnsmasq",
+			parse: parseDnsmasqLeases,
+			data:  dnsmasqSample,
+			want: []string{
+				"192.168.1.20 00:11:22:33:44:55 printer active",
+				"192.168.1.21 00:11:22:33:44:66  inactive", // expired
+				"192.168.1.22 00:11:22:33:44:77 static active",
+				// IPv6 lines carry an IAID instead of a MAC
+				"2001:db8::20  host6 active",
+				"2001:db8::21   inactive",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leases, err := tt.parse(tt.data, leaseNow)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			var got []string
+			for _, l := range leases {
+				got = append(got, leaseSummary(l))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("leases:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseLeasesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string, time.Time) ([]dhcpLease, error)
+		data  string
+		want  string
+	}{
+		{"isc unterminated", parseISCLeases, "lease 10.0.0.1 {\n  binding state active;\n", "unterminated lease block"},
+		{"isc bad address", parseISCLeases, "lease 10.0.0.300 {\n}\n", "line 1: invalid lease address"},
+		{"isc bad end", parseISCLeases, "lease 10.0.0.1 {\n  ends epoch soon;\n}\n", "line 2: invalid lease end"},
+		{"kea no address column", parseKeaLeases, "hwaddr,expire\n00:11:22:33:44:55,0\n", "missing 'address' column"},
+		{"kea bad expire", parseKeaLeases, "address,expire\n10.0.0.1,tomorrow\n", "invalid expire time"},
+		{"dnsmasq short line", parseDnsmasqLeases, "1792087200 00:11:22:33:44:55\n", "line 1: expected at least 3 fields"},
+		{"dnsmasq bad expiry", parseDnsmasqLeases, "soon 00:11:22:33:44:55 10.0.0.1 * *\n", "line 1: invalid expiry time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parse(tt.data, leaseNow)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLeaseFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"isc", iscSample, "isc"},
+		{"isc server-duid", "server-duid \"\\000\\001\";\n", "isc"},
+		{"kea", keaSample, "kea"},
+		{"kea lease6", kea6Sample, "kea"},
+		{"dnsmasq", dnsmasqSample, "dnsmasq"},
+		{"dnsmasq duid first", "duid 00:01:00:01\n", "dnsmasq"},
+		{"empty", "", "dnsmasq"},
+		{"comments only", "# nothing here\n\n", "dnsmasq"},
+		{"unknown", "<leases><lease ip=\"10.0.0.1\"/></leases>\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLeaseFormat(tt.data); got != tt.want {
+				t.Errorf("detectLeaseFormat = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLeases(t *testing.T) {
+	dir := t.TempDir()
+
+	// Later records for the same address replace earlier ones
+	path := filepath.Join(dir, "dnsmasq.leases")
+	data := "1792044000 00:11:22:33:44:55 10.0.0.2 old *\n" +
+		"1792087200 00:11:22:33:44:66 10.0.0.1 * *\n" +
+		"1792087200 00:11:22:33:44:55 10.0.0.2 new *\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	leases, err := loadLeases(path, "auto", leaseNow)
+	if err != nil {
+		t.Fatalf("loadLeases: %v", err)
+	}
+	var got []string
+	for _, l := range leases {
+		got = append(got, leaseSummary(l))
+	}
+	want := "10.0.0.1 00:11:22:33:44:66  active\n10.0.0.2 00:11:22:33:44:55 new active"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("leases:\n%s\nwant:\n%s", strings.Join(got, "\n"), want)
+	}
+
+	unknown := filepath.Join(dir, "leases.xml")
+	if err := os.WriteFile(unknown, []byte("<leases/>\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadLeases(unknown, "auto", leaseNow); err == nil || !strings.Contains(err.Error(), "could not detect") {
+		t.Errorf("undetectable file: error = %v", err)
+	}
+	if _, err := loadLeases(unknown, "bind", leaseNow); err == nil || !strings.Contains(err.Error(), "unknown lease format") {
+		t.Errorf("unknown --format: error = %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/trahma/cidr/cidrset"
+)
+
+// rangeMatcher answers which configured ranges, and which groups, contain
+// an address. It is built once and reused for many lookups.
+type rangeMatcher struct {
+	entries []matcherEntry
+}
+
+type matcherEntry struct {
+	Net   *net.IPNet
+	Group string
+}
+
+// newConfigMatcher builds a matcher from the --group or every config group.
+func newConfigMatcher() (*rangeMatcher, error) {
+	sets, err := collectExportSets(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRangeMatcher(sets), nil
+}
+
+func newRangeMatcher(sets []exportSet) *rangeMatcher {
+	m := &rangeMatcher{}
+	for _, set := range sets {
+		for _, ipnet := range set.CIDRs {
+			m.entries = append(m.entries, matcherEntry{Net: ipnet, Group: set.Name})
+		}
+	}
+	return m
+}
+
+// match returns every entry containing ip, in group order.
+func (m *rangeMatcher) match(ip net.IP) []matcherEntry {
+	var matches []matcherEntry
+	for _, entry := range m.entries {
+		if entry.Net.Contains(ip) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// matchEngine finds every range containing an address. Engines must agree
+// on the entries returned, in any order; 'cidr shadow' compares them.
+type matchEngine interface {
+	match(ip net.IP) []matcherEntry
+}
+
+// matchEngines builds each engine from the ranges to match. New engines are
+// added here and can then be checked against the others with 'cidr shadow'.
+var matchEngines = map[string]func(sets []exportSet) matchEngine{
+	"linear": func(sets []exportSet) matchEngine { return newRangeMatcher(sets) },
+	"trie":   func(sets []exportSet) matchEngine { return newTrieMatcher(sets) },
+}
+
+// trieMatcher is a matchEngine backed by a cidrset.Trie, for large range
+// lists.
+type trieMatcher struct {
+	trie cidrset.Trie[matcherEntry]
+}
+
+func newTrieMatcher(sets []exportSet) *trieMatcher {
+	m := &trieMatcher{}
+	for _, set := range sets {
+		for _, ipnet := range set.CIDRs {
+			addr, _ := netip.AddrFromSlice(ipnet.IP)
+			ones, _ := ipnet.Mask.Size()
+			network, err := cidrset.NetworkFromPrefix(netip.PrefixFrom(addr, ones))
+			if err != nil {
+				continue
+			}
+			m.trie.Insert(network, matcherEntry{Net: ipnet, Group: set.Name})
+		}
+	}
+	return m
+}
+
+// match returns every entry containing ip, from the shortest prefix to the
+// longest.
+func (m *trieMatcher) match(ip net.IP) []matcherEntry {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return nil
+	}
+	return m.trie.Lookup(addr)
+}
+
+// matchedGroups returns the distinct group names of a set of matches.
+func matchedGroups(matches []matcherEntry) []string {
+	groups := []string{}
+	seen := make(map[string]bool)
+	for _, entry := range matches {
+		if !seen[entry.Group] {
+			seen[entry.Group] = true
+			groups = append(groups, entry.Group)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMatchEnginesAgree(t *testing.T) {
+	var sets []exportSet
+	for _, group := range []struct {
+		name  string
+		cidrs []string
+	}{
+		{"private", []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}},
+		{"office", []string{"10.1.0.0/16", "10.1.2.0/24", "192.168.1.10/32", "2001:db8:1::/48"}},
+		{"vpn", []string{"10.1.2.128/25", "10.1.2.255/32", "2001:db8::/32", "2001:db8:1:2::/64"}},
+		{"mapped", []string{"::ffff:10.1.0.0/112", "::ffff:192.0.2.1/128"}},
+		{"everything", []string{"0.0.0.0/0", "::/0"}},
+		{"empty", nil},
+	} {
+		set, err := newExportSet(group.name, group.cidrs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sets = append(sets, set)
+	}
+	// A second config range without a catch-all, so that some queries
+	// match nothing
+	narrow, err := newExportSet("narrow", []string{"198.51.100.0/24", "2001:db8:ff::/48"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []string{
+		// Network and broadcast addresses
+		"10.0.0.0", "10.255.255.255", "10.1.0.0", "10.1.255.255",
+		"10.1.2.0", "10.1.2.127", "10.1.2.128", "10.1.2.255",
+		"172.16.0.0", "172.31.255.255", "192.168.1.10",
+		"2001:db8::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff",
+		"2001:db8:1:2::", "2001:db8:1:2:ffff:ffff:ffff:ffff",
+		// Just outside a range
+		"9.255.255.255", "11.0.0.0", "172.32.0.0", "192.168.1.11", "2001:db9::",
+		// IPv4-mapped queries match IPv4 ranges
+		"::ffff:10.1.2.200", "::ffff:192.0.2.1", "::ffff:192.0.2.2", "::ffff:0.0.0.0",
+		"0.0.0.0", "255.255.255.255", "::", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+		"198.51.100.7", "2001:db8:ff::1",
+	}
+
+	for _, config := range []struct {
+		name string
+		sets []exportSet
+	}{
+		{"all groups", append(sets, narrow)},
+		{"narrow only", []exportSet{narrow}},
+		{"none", nil},
+	} {
+		t.Run(config.name, func(t *testing.T) {
+			linear := matchEngines["linear"](config.sets)
+			trie := matchEngines["trie"](config.sets)
+			for _, q := range queries {
+				ip := net.ParseIP(q)
+				want, got := linear.match(ip), trie.match(ip)
+				if !sameEntries(want, got) {
+					t.Errorf("%s: linear %s, trie %s", q, formatEntries(want), formatEntries(got))
+				}
+			}
+		})
+	}
+
+	// Spot-check the linear engine so that agreement means something
+	linear := matchEngines["linear"](append(sets, narrow))
+	for q, want := range map[string]string{
+		"10.1.2.255":       "0.0.0.0/0 (everything), 10.0.0.0/8 (private), 10.1.0.0/16 (mapped), 10.1.0.0/16 (office), 10.1.2.0/24 (office), 10.1.2.128/25 (vpn), 10.1.2.255/32 (vpn)",
+		"::ffff:192.0.2.1": "0.0.0.0/0 (everything), 192.0.2.1/32 (mapped)",
+		"2001:db8:1:2::":   "2001:db8:1:2::/64 (vpn), 2001:db8:1::/48 (office), 2001:db8::/32 (vpn), ::/0 (everything)",
+		"198.51.100.7":     "0.0.0.0/0 (everything), 198.51.100.0/24 (narrow)",
+		"2001:db9::":       "::/0 (everything)",
+		"172.32.0.0":       "0.0.0.0/0 (everything)",
+		"::ffff:192.0.2.2": "0.0.0.0/0 (everything)",
+	} {
+		if got := formatEntries(linear.match(net.ParseIP(q))); got != want {
+			t.Errorf("%s: linear %s, want %s", q, got, want)
+		}
+	}
+	narrowOnly := matchEngines["trie"]([]exportSet{narrow})
+	if got := narrowOnly.match(net.ParseIP("10.0.0.1")); len(got) != 0 {
+		t.Errorf("10.0.0.1: trie %s, want no match", formatEntries(got))
+	}
+}
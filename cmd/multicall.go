@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// multiCallCommands replace the root command when the binary is invoked
+// under another tool's name, e.g. through a symlink:
+//
+//	ln -s "$(command -v cidr)" /usr/local/bin/ipcalc
+//
+// They parse arguments and print output like the original tool, without
+// styling, so existing scripts keep working.
+var multiCallCommands = map[string]*cobra.Command{}
+
+// registerMultiCall makes cmd the entry point when the binary is run as name.
+func registerMultiCall(name string, cmd *cobra.Command) {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.CompletionOptions.DisableDefaultCmd = true
+	multiCallCommands[name] = cmd
+}
+
+// invokedName is the name the binary was run as, without directory or
+// Windows executable suffix.
+func invokedName() string {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+}
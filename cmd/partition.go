@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	partitionShards    int
+	partitionSample    string
+	partitionSeed      uint64
+	partitionMaxSample int
+	partitionOutput    string
+)
+
+var partitionCmd = &cobra.Command{
+	Use:   "partition [list file]",
+	Short: "Split an IP/CIDR list into balanced shards or sample it",
+	Long: titleStyle.Render("Partition Address Lists") + "\n\n" +
+		"Split a list of IPs and CIDRs into shards holding the same number of addresses\n" +
+		"(not the same number of lines), so scanning or checking work can be spread\n" +
+		"evenly across workers. Overlapping entries are merged first and ranges are\n" +
+		"split across shard boundaries where needed.\n\n" +
+		"With --sample, a uniform random sample of the address space is taken first\n" +
+		"and the sampled addresses are sharded. The sample size is a percentage of\n" +
+		"all addresses (e.g. 1%) or an absolute count (e.g. 5000).\n\n" +
+		"Reads the list from stdin when the file is '-'.",
+	Example: `  cidr partition targets.txt --shards 8 --output shards/
+  cidr partition targets.txt --sample 1% --seed 42
+  cidr partition - --sample 10000 --shards 4 < ranges.txt`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPartition,
+}
+
+func init() {
+	partitionCmd.Flags().IntVarP(&partitionShards, "shards", "n", 1, "Number of shards to split the list into")
+	partitionCmd.Flags().StringVar(&partitionSample, "sample", "", "Sample this share (e.g. 1%) or number of addresses")
+	partitionCmd.Flags().Uint64Var(&partitionSeed, "seed", 0, "Random seed for --sample (random when not set)")
+	partitionCmd.Flags().IntVar(&partitionMaxSample, "max-sample", 1000000, "Maximum number of addresses --sample may produce")
+	partitionCmd.Flags().StringVarP(&partitionOutput, "output", "o", "", "Write shard-N.txt files to this directory instead of stdout")
+	rootCmd.AddCommand(partitionCmd)
+}
+
+// addressInterval is an inclusive range of addresses as integers. Bits is 32
+// for IPv4 and 128 for IPv6.
+type addressInterval struct {
+	Start, End *big.Int
+	Bits       int
+}
+
+func (iv addressInterval) size() *big.Int {
+	size := new(big.Int).Sub(iv.End, iv.Start)
+	return size.Add(size, big.NewInt(1))
+}
+
+// partitionShard is one output shard: ranges, or individual sampled addresses.
+type partitionShard struct {
+	Entries   []string
+	Addresses *big.Int
+}
+
+func runPartition(cmd *cobra.Command, args []string) error {
+	if partitionShards < 1 {
+		return fmt.Errorf("--shards must be at least 1")
+	}
+
+	lines, err := readListFile(args[0])
+	if err != nil {
+		return err
+	}
+	intervals, err := parseAddressIntervals(lines)
+	if err != nil {
+		return err
+	}
+	if len(intervals) == 0 {
+		return fmt.Errorf("no addresses in %s", args[0])
+	}
+
+	total := new(big.Int)
+	for _, iv := range intervals {
+		total.Add(total, iv.size())
+	}
+
+	var shards []partitionShard
+	var summary []string
+	summary = append(summary, fmt.Sprintf("%s %s", labelStyle.Render("Input:"),
+		valueStyle.Render(fmt.Sprintf("%d entries, %s addresses", len(lines), total))))
+
+	if partitionSample != "" {
+		count, err := parseSampleSize(partitionSample, total)
+		if err != nil {
+			return err
+		}
+		if count.Cmp(big.NewInt(int64(partitionMaxSample))) > 0 {
+			return fmt.Errorf("sample of %s addresses is more than --max-sample %d", count, partitionMaxSample)
+		}
+		if !cmd.Flags().Changed("seed") {
+			partitionSeed = uint64(time.Now().UnixNano())
+		}
+
+		sample := sampleAddresses(intervals, total, int(count.Int64()), rand.New(rand.NewPCG(partitionSeed, partitionSeed)))
+		shards = splitSample(sample, partitionShards)
+		summary = append(summary, fmt.Sprintf("%s %s", labelStyle.Render("Sample:"),
+			valueStyle.Render(fmt.Sprintf("%d addresses (seed %d)", len(sample), partitionSeed))))
+	} else {
+		shards = splitIntervals(intervals, total, partitionShards)
+	}
+
+	if partitionOutput == "" {
+		for i, shard := range shards {
+			if partitionShards > 1 {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("# shard %d/%d: %s addresses\n", i+1, len(shards), shard.Addresses)
+			}
+			for _, entry := range shard.Entries {
+				fmt.Println(entry)
+			}
+		}
+		for _, line := range summary {
+			fmt.Fprintln(os.Stderr, dimStyle.Render(line))
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(partitionOutput, 0o755); err != nil {
+		return err
+	}
+	fmt.Println(titleStyle.Render("Partition"))
+	for _, line := range summary {
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	width := len(fmt.Sprint(len(shards)))
+	for i, shard := range shards {
+		path := filepath.Join(partitionOutput, fmt.Sprintf("shard-%0*d.txt", width, i+1))
+		if err := writeShard(path, shard); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s %s\n", successStyle.Render("✓"), valueStyle.Render(path),
+			dimStyle.Render(fmt.Sprintf("%d entries, %s addresses", len(shard.Entries), shard.Addresses)))
+	}
+
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr partition --help' for more options"))
+
+	return nil
+}
+
+func writeShard(path string, shard partitionShard) error {
+	var data string
+	if len(shard.Entries) > 0 {
+		data = strings.Join(shard.Entries, "\n") + "\n"
+	}
+	return os.WriteFile(path, []byte(data), 0o644)
+}
+
+// parseAddressIntervals parses IPs and CIDRs into sorted intervals with
+// overlapping and adjacent entries merged, IPv4 before IPv6.
+func parseAddressIntervals(lines []string) ([]addressInterval, error) {
+	var intervals []addressInterval
+	for _, line := range lines {
+		ipnet, err := parseIPOrCIDR(line)
+		if err != nil {
+			return nil, err
+		}
+		start, bits := ipToInt(ipnet.IP)
+		end, _ := ipToInt(getBroadcastIP(ipnet))
+		intervals = append(intervals, addressInterval{Start: start, End: end, Bits: bits})
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		if intervals[i].Bits != intervals[j].Bits {
+			return intervals[i].Bits < intervals[j].Bits
+		}
+		return intervals[i].Start.Cmp(intervals[j].Start) < 0
+	})
+
+	var merged []addressInterval
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 && merged[n-1].Bits == iv.Bits {
+			last := &merged[n-1]
+			next := new(big.Int).Add(last.End, big.NewInt(1))
+			if iv.Start.Cmp(next) <= 0 {
+				if iv.End.Cmp(last.End) > 0 {
+					last.End = iv.End
+				}
+				continue
+			}
+		}
+		merged = append(merged, iv)
+	}
+	return merged, nil
+}
+
+// parseIPOrCIDR parses a CIDR, or a single address as a /32 or /128.
+func parseIPOrCIDR(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, ipnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR notation '%s': %w", s, err)
+		}
+		return ipnet, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", s)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+func ipToInt(ip net.IP) (*big.Int, int) {
+	if v4 := ip.To4(); v4 != nil {
+		return new(big.Int).SetBytes(v4), 32
+	}
+	return new(big.Int).SetBytes(ip.To16()), 128
+}
+
+func intToIP(n *big.Int, bits int) net.IP {
+	ip := make(net.IP, bits/8)
+	n.FillBytes(ip)
+	return ip
+}
+
+// splitIntervals divides the intervals into shards of equal address count
+// (differing by at most one), splitting intervals at shard boundaries.
+func splitIntervals(intervals []addressInterval, total *big.Int, shards int) []partitionShard {
+	n := big.NewInt(int64(shards))
+	quota, extra := new(big.Int).QuoRem(total, n, new(big.Int))
+
+	result := make([]partitionShard, shards)
+	current := 0
+	var pos *big.Int // Next unassigned address in intervals[current]
+	if len(intervals) > 0 {
+		pos = new(big.Int).Set(intervals[0].Start)
+	}
+	for s := range result {
+		want := new(big.Int).Set(quota)
+		if big.NewInt(int64(s)).Cmp(extra) < 0 {
+			want.Add(want, big.NewInt(1))
+		}
+		result[s].Addresses = new(big.Int).Set(want)
+
+		for want.Sign() > 0 {
+			iv := intervals[current]
+			left := new(big.Int).Sub(iv.End, pos)
+			left.Add(left, big.NewInt(1))
+			take := left
+			if want.Cmp(left) < 0 {
+				take = new(big.Int).Set(want)
+			}
+
+			end := new(big.Int).Add(pos, take)
+			end.Sub(end, big.NewInt(1))
+			for _, ipnet := range rangeToCIDRs(intToIP(pos, iv.Bits), intToIP(end, iv.Bits)) {
+				result[s].Entries = append(result[s].Entries, ipnet.String())
+			}
+
+			want.Sub(want, take)
+			if end.Cmp(iv.End) == 0 {
+				current++
+				if current < len(intervals) {
+					pos = new(big.Int).Set(intervals[current].Start)
+				}
+			} else {
+				pos = end.Add(end, big.NewInt(1))
+			}
+		}
+	}
+	return result
+}
+
+// splitSample divides sampled addresses into shards of equal size, keeping
+// each shard's addresses contiguous in sorted order.
+func splitSample(sample []net.IP, shards int) []partitionShard {
+	result := make([]partitionShard, shards)
+	start := 0
+	for s := range result {
+		size := len(sample) / shards
+		if s < len(sample)%shards {
+			size++
+		}
+		for _, ip := range sample[start : start+size] {
+			result[s].Entries = append(result[s].Entries, ip.String())
+		}
+		result[s].Addresses = big.NewInt(int64(size))
+		start += size
+	}
+	return result
+}
+
+// parseSampleSize turns "1%", "0.5%" or "5000" into a number of addresses,
+// rounding percentages down but never below one.
+func parseSampleSize(spec string, total *big.Int) (*big.Int, error) {
+	value, isPercent := strings.CutSuffix(strings.TrimSpace(spec), "%")
+	r, ok := new(big.Rat).SetString(value)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid sample size '%s' (use a percentage such as 1%% or a count)", spec)
+	}
+
+	if !isPercent {
+		if !r.IsInt() {
+			return nil, fmt.Errorf("invalid sample size '%s': a count must be a whole number", spec)
+		}
+		count := new(big.Int).Set(r.Num())
+		if count.Cmp(total) > 0 {
+			return nil, fmt.Errorf("sample of %s addresses is more than the %s in the list", count, total)
+		}
+		return count, nil
+	}
+
+	if r.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, fmt.Errorf("invalid sample size '%s': more than 100%%", spec)
+	}
+	r.Mul(r, new(big.Rat).SetInt(total))
+	r.Quo(r, big.NewRat(100, 1))
+	count := new(big.Int).Quo(r.Num(), r.Denom())
+	if count.Sign() == 0 {
+		count.SetInt64(1)
+	}
+	return count, nil
+}
+
+// sampleAddresses picks count distinct addresses uniformly at random from
+// the intervals and returns them in address order.
+func sampleAddresses(intervals []addressInterval, total *big.Int, count int, rng *rand.Rand) []net.IP {
+	var offsets []*big.Int
+	if total.Cmp(big.NewInt(int64(2*count))) <= 0 {
+		// Dense sample: selection sampling visits every offset once
+		// (Knuth's Algorithm S), total is small enough to walk
+		n := total.Int64()
+		for i := int64(0); i < n && len(offsets) < count; i++ {
+			if rng.Int64N(n-i) < int64(count-len(offsets)) {
+				offsets = append(offsets, big.NewInt(i))
+			}
+		}
+	} else {
+		// Sparse sample: draw random offsets and reject repeats
+		seen := make(map[string]bool, count)
+		for len(offsets) < count {
+			offset := randomBigInt(rng, total)
+			if !seen[offset.String()] {
+				seen[offset.String()] = true
+				offsets = append(offsets, offset)
+			}
+		}
+		sort.Slice(offsets, func(i, j int) bool { return offsets[i].Cmp(offsets[j]) < 0 })
+	}
+
+	// Map sorted offsets onto the intervals in one pass
+	ips := make([]net.IP, 0, len(offsets))
+	current := 0
+	base := new(big.Int) // Offset of intervals[current].Start
+	for _, offset := range offsets {
+		for {
+			end := new(big.Int).Add(base, intervals[current].size())
+			if offset.Cmp(end) < 0 {
+				break
+			}
+			base = end
+			current++
+		}
+		addr := new(big.Int).Sub(offset, base)
+		addr.Add(addr, intervals[current].Start)
+		ips = append(ips, intToIP(addr, intervals[current].Bits))
+	}
+	return ips
+}
+
+// randomBigInt returns a uniform random integer in [0, max). Drawing 64 bits
+// more than max needs keeps the modulo bias negligible.
+func randomBigInt(rng *rand.Rand, max *big.Int) *big.Int {
+	buf := make([]byte, len(max.Bytes())+8)
+	for i := 0; i < len(buf); i += 8 {
+		var word [8]byte
+		v := rng.Uint64()
+		for j := range word {
+			word[j] = byte(v >> (8 * j))
+		}
+		copy(buf[i:], word[:])
+	}
+	n := new(big.Int).SetBytes(buf)
+	return n.Mod(n, max)
+}
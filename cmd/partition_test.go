@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"math/big"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mustIntervals parses ranges for a test and returns them with their total
+// address count.
+func mustIntervals(t *testing.T, lines ...string) ([]addressInterval, *big.Int) {
+	t.Helper()
+	intervals, err := parseAddressIntervals(lines)
+	if err != nil {
+		t.Fatalf("parseAddressIntervals: %v", err)
+	}
+	total := new(big.Int)
+	for _, iv := range intervals {
+		total.Add(total, iv.size())
+	}
+	return intervals, total
+}
+
+func TestSplitIntervals(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []string
+		shards int
+		want   [][]string
+	}{
+		{
+			name:   "even split",
+			ranges: []string{"10.0.0.0/24"},
+			shards: 4,
+			want: [][]string{
+				{"10.0.0.0/26"},
+				{"10.0.0.64/26"},
+				{"10.0.0.128/26"},
+				{"10.0.0.192/26"},
+			},
+		},
+		{
+			name:   "uneven split",
+			ranges: []string{"10.0.0.0/30", "10.0.0.8"},
+			shards: 2,
+			want: [][]string{
+				{"10.0.0.0/31", "10.0.0.2/32"},
+				{"10.0.0.3/32", "10.0.0.8/32"},
+			},
+		},
+		{
+			name:   "more shards than addresses",
+			ranges: []string{"192.0.2.1", "192.0.2.2"},
+			shards: 3,
+			want: [][]string{
+				{"192.0.2.1/32"},
+				{"192.0.2.2/32"},
+				nil,
+			},
+		},
+		{
+			name:   "overlapping and mixed families",
+			ranges: []string{"2001:db8::/126", "10.0.0.0/31", "10.0.0.1", "2001:db8::2/127"},
+			shards: 3,
+			want: [][]string{
+				{"10.0.0.0/31"},
+				{"2001:db8::/127"},
+				{"2001:db8::2/127"},
+			},
+		},
+		{
+			name:   "shard boundary inside a range",
+			ranges: []string{"10.0.0.0/29"},
+			shards: 3,
+			want: [][]string{
+				{"10.0.0.0/31", "10.0.0.2/32"},
+				{"10.0.0.3/32", "10.0.0.4/31"},
+				{"10.0.0.6/31"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intervals, total := mustIntervals(t, tt.ranges...)
+			shards := splitIntervals(intervals, total, tt.shards)
+			if len(shards) != tt.shards {
+				t.Fatalf("got %d shards, want %d", len(shards), tt.shards)
+			}
+
+			sum := new(big.Int)
+			lowest, highest := shards[0].Addresses, shards[0].Addresses
+			for i, shard := range shards {
+				if !slices.Equal(shard.Entries, tt.want[i]) {
+					t.Errorf("shard %d = %v, want %v", i, shard.Entries, tt.want[i])
+				}
+
+				// The reported size must match the ranges in the shard
+				size := new(big.Int)
+				for _, entry := range shard.Entries {
+					_, ipnet, err := net.ParseCIDR(entry)
+					if err != nil {
+						t.Fatalf("shard %d: %v", i, err)
+					}
+					ones, bits := ipnet.Mask.Size()
+					size.Add(size, new(big.Int).Lsh(big.NewInt(1), uint(bits-ones)))
+				}
+				if size.Cmp(shard.Addresses) != 0 {
+					t.Errorf("shard %d holds %s addresses but reports %s", i, size, shard.Addresses)
+				}
+
+				sum.Add(sum, shard.Addresses)
+				if shard.Addresses.Cmp(lowest) < 0 {
+					lowest = shard.Addresses
+				}
+				if shard.Addresses.Cmp(highest) > 0 {
+					highest = shard.Addresses
+				}
+			}
+			if sum.Cmp(total) != 0 {
+				t.Errorf("shards hold %s addresses, want %s", sum, total)
+			}
+			if diff := new(big.Int).Sub(highest, lowest); diff.Cmp(big.NewInt(1)) > 0 {
+				t.Errorf("shard sizes range from %s to %s, want a difference of at most 1", lowest, highest)
+			}
+		})
+	}
+}
+
+func TestSampleAddresses(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []string
+		count  int
+	}{
+		{"dense", []string{"10.0.0.0/28", "10.0.1.0/30"}, 15},
+		{"whole list", []string{"10.0.0.0/29"}, 8},
+		{"sparse", []string{"10.0.0.0/8", "2001:db8::/64"}, 50},
+		{"single address", []string{"192.0.2.1"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intervals, total := mustIntervals(t, tt.ranges...)
+			sample := sampleAddresses(intervals, total, tt.count, rand.New(rand.NewPCG(1, 1)))
+			if len(sample) != tt.count {
+				t.Fatalf("got %d addresses, want %d", len(sample), tt.count)
+			}
+
+			var nets []*net.IPNet
+			for _, r := range tt.ranges {
+				ipnet, _ := parseIPOrCIDR(r)
+				nets = append(nets, ipnet)
+			}
+			seen := make(map[string]bool)
+			for _, ip := range sample {
+				if seen[ip.String()] {
+					t.Errorf("%s sampled twice", ip)
+				}
+				seen[ip.String()] = true
+				if !slices.ContainsFunc(nets, func(n *net.IPNet) bool { return n.Contains(ip) }) {
+					t.Errorf("%s is not in %v", ip, tt.ranges)
+				}
+			}
+
+			// The same seed must give the same sample
+			again := sampleAddresses(intervals, total, tt.count, rand.New(rand.NewPCG(1, 1)))
+			if !slices.EqualFunc(sample, again, net.IP.Equal) {
+				t.Errorf("seed 1 gave %v, then %v", sample, again)
+			}
+		})
+	}
+}
+
+func TestSampleAddressesFixedSeed(t *testing.T) {
+	intervals, total := mustIntervals(t, "10.0.0.0/24")
+	sample := sampleAddresses(intervals, total, 4, rand.New(rand.NewPCG(42, 42)))
+	var got []string
+	for _, ip := range sample {
+		got = append(got, ip.String())
+	}
+	want := "10.0.0.31 10.0.0.34 10.0.0.223 10.0.0.227"
+	if strings.Join(got, " ") != want {
+		t.Errorf("sample = %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestParseSampleSize(t *testing.T) {
+	tests := []struct {
+		spec    string
+		total   int64
+		want    int64
+		wantErr string
+	}{
+		{spec: "10", total: 256, want: 10},
+		{spec: "256", total: 256, want: 256},
+		{spec: "1%", total: 256, want: 2},
+		{spec: "50%", total: 256, want: 128},
+		{spec: "100%", total: 256, want: 256},
+		{spec: "0.5%", total: 256, want: 1},
+		{spec: "0.1%", total: 256, want: 1}, // rounds up to one address
+		{spec: " 25% ", total: 8, want: 2},
+		{spec: "0", total: 256, wantErr: "invalid sample size"},
+		{spec: "0%", total: 256, wantErr: "invalid sample size"},
+		{spec: "-1", total: 256, wantErr: "invalid sample size"},
+		{spec: "150%", total: 256, wantErr: "more than 100%"},
+		{spec: "257", total: 256, wantErr: "more than the 256 in the list"},
+		{spec: "2.5", total: 256, wantErr: "a count must be a whole number"},
+		{spec: "some", total: 256, wantErr: "invalid sample size"},
+		{spec: "", total: 256, wantErr: "invalid sample size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseSampleSize(tt.spec, big.NewInt(tt.total))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSampleSize: %v", err)
+			}
+			if got.Int64() != tt.want {
+				t.Errorf("parseSampleSize = %s, want %d", got, tt.want)
+			}
+		})
+	}
+}
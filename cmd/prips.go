@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	pripsCIDR      bool
+	pripsDelimiter int
+	pripsExclude   string
+	pripsFormat    string
+	pripsIncrement int
+)
+
+// pripsCmd follows prips: print every address in a range, one per line.
+var pripsCmd = &cobra.Command{
+	Use:   "prips [options] START END | CIDR",
+	Short: "prips-compatible address range printer",
+	Long: "Print the IP addresses in a range, compatible with prips.\n\n" +
+		"The range is given as a start and end address or as a CIDR block, and\n" +
+		"includes both ends (network and broadcast addresses are printed).",
+	Example: `  prips 192.168.1.0/30
+  prips -i 4 -f hex 10.0.0.0 10.0.0.32
+  prips -e ...0,255 10.0.0.0/23`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runPrips,
+}
+
+func init() {
+	flags := pripsCmd.Flags()
+	flags.BoolVarP(&pripsCIDR, "cidr", "c", false, "Print the range in CIDR notation instead of listing addresses")
+	flags.IntVarP(&pripsDelimiter, "delimiter", "d", 10, "ASCII code of the delimiter printed after each address")
+	flags.StringVarP(&pripsExclude, "exclude", "e", "", "Exclude addresses by octet, e.g. ...0,255 (IPv4 only)")
+	flags.StringVarP(&pripsFormat, "format", "f", "dot", "Address format: dot, dec or hex")
+	flags.IntVarP(&pripsIncrement, "increment", "i", 1, "Print every Nth address")
+	registerMultiCall("prips", pripsCmd)
+}
+
+func runPrips(cmd *cobra.Command, args []string) error {
+	start, end, err := parsePripsRange(args)
+	if err != nil {
+		return err
+	}
+	if pripsDelimiter < 0 || pripsDelimiter > 255 {
+		return fmt.Errorf("delimiter must be between 0 and 255")
+	}
+	if pripsIncrement < 1 {
+		return fmt.Errorf("increment must be at least 1")
+	}
+	if pripsFormat != "dot" && pripsFormat != "dec" && pripsFormat != "hex" {
+		return fmt.Errorf("invalid format '%s' (use dot, dec or hex)", pripsFormat)
+	}
+	exclude, err := parsePripsExclude(pripsExclude, len(start))
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	delim := string([]byte{byte(pripsDelimiter)})
+
+	if pripsCIDR {
+		for _, ipnet := range rangeToCIDRs(start, end) {
+			fmt.Fprint(out, ipnet.String()+delim)
+		}
+		return nil
+	}
+
+	lo := new(big.Int).SetBytes(start)
+	hi := new(big.Int).SetBytes(end)
+	incr := big.NewInt(int64(pripsIncrement))
+	ip := make(net.IP, len(start))
+	for ; lo.Cmp(hi) <= 0; lo.Add(lo, incr) {
+		lo.FillBytes(ip)
+		if excluded(ip, exclude) {
+			continue
+		}
+		switch pripsFormat {
+		case "dec":
+			fmt.Fprint(out, lo.String()+delim)
+		case "hex":
+			fmt.Fprint(out, lo.Text(16)+delim)
+		default:
+			fmt.Fprint(out, ip.String()+delim)
+		}
+	}
+	return nil
+}
+
+// parsePripsRange accepts START END or a CIDR block and returns both ends
+// in the same byte length.
+func parsePripsRange(args []string) (net.IP, net.IP, error) {
+	if len(args) == 1 {
+		_, ipnet, err := net.ParseCIDR(args[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("bad CIDR block: %s", args[0])
+		}
+		return ipnet.IP, getBroadcastIP(ipnet), nil
+	}
+
+	start, end := net.ParseIP(args[0]), net.ParseIP(args[1])
+	if start == nil {
+		return nil, nil, fmt.Errorf("bad IP address: %s", args[0])
+	}
+	if end == nil {
+		return nil, nil, fmt.Errorf("bad IP address: %s", args[1])
+	}
+	if (start.To4() == nil) != (end.To4() == nil) {
+		return nil, nil, fmt.Errorf("start and end addresses must be the same family")
+	}
+	if start.To4() != nil {
+		start, end = start.To4(), end.To4()
+	}
+	if compareIPs(start, end) > 0 {
+		return nil, nil, fmt.Errorf("start address must be smaller than end address")
+	}
+	return start, end, nil
+}
+
+// parsePripsExclude parses an exclusion like "...0,255": four dot-separated
+// octet positions, each an optional comma-separated list of values.
+func parsePripsExclude(spec string, length int) ([]map[byte]bool, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	if length != net.IPv4len {
+		return nil, fmt.Errorf("exclusions are only supported for IPv4")
+	}
+
+	fields := strings.Split(spec, ".")
+	if len(fields) != net.IPv4len {
+		return nil, fmt.Errorf("bad exclusion '%s': expected four dot-separated octets", spec)
+	}
+	exclude := make([]map[byte]bool, net.IPv4len)
+	for i, field := range fields {
+		exclude[i] = make(map[byte]bool)
+		if field == "" {
+			continue
+		}
+		for _, value := range strings.Split(field, ",") {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > 255 {
+				return nil, fmt.Errorf("bad exclusion '%s': invalid octet '%s'", spec, value)
+			}
+			exclude[i][byte(n)] = true
+		}
+	}
+	return exclude, nil
+}
+
+func excluded(ip net.IP, exclude []map[byte]bool) bool {
+	for i, octets := range exclude {
+		if octets[ip[i]] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePripsRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		start   string
+		end     string
+		length  int
+		wantErr string
+	}{
+		{name: "CIDR", args: []string{"192.168.1.0/30"}, start: "192.168.1.0", end: "192.168.1.3", length: 4},
+		{name: "CIDR with host bits", args: []string{"192.168.1.5/30"}, start: "192.168.1.4", end: "192.168.1.7", length: 4},
+		{name: "single address", args: []string{"10.0.0.1/32"}, start: "10.0.0.1", end: "10.0.0.1", length: 4},
+		{name: "IPv6 CIDR", args: []string{"2001:db8::/126"}, start: "2001:db8::", end: "2001:db8::3", length: 16},
+		{name: "start and end", args: []string{"10.0.0.0", "10.0.0.32"}, start: "10.0.0.0", end: "10.0.0.32", length: 4},
+		{name: "start equals end", args: []string{"10.0.0.5", "10.0.0.5"}, start: "10.0.0.5", end: "10.0.0.5", length: 4},
+		{name: "IPv6 start and end", args: []string{"2001:db8::1", "2001:db8::1:0"}, start: "2001:db8::1", end: "2001:db8::1:0", length: 16},
+		{name: "IPv4-mapped start", args: []string{"::ffff:10.0.0.1", "10.0.0.2"}, start: "10.0.0.1", end: "10.0.0.2", length: 4},
+		{name: "bare address", args: []string{"10.0.0.0"}, wantErr: "bad CIDR block: 10.0.0.0"},
+		{name: "bad prefix", args: []string{"10.0.0.0/33"}, wantErr: "bad CIDR block: 10.0.0.0/33"},
+		{name: "bad start", args: []string{"10.0.0.x", "10.0.0.1"}, wantErr: "bad IP address: 10.0.0.x"},
+		{name: "bad end", args: []string{"10.0.0.1", "10.0.0.0/24"}, wantErr: "bad IP address: 10.0.0.0/24"},
+		{name: "mixed families", args: []string{"10.0.0.1", "::1"}, wantErr: "start and end addresses must be the same family"},
+		{name: "reversed", args: []string{"10.0.0.2", "10.0.0.1"}, wantErr: "start address must be smaller than end address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := parsePripsRange(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePripsRange: %v", err)
+			}
+			if start.String() != tt.start || end.String() != tt.end {
+				t.Errorf("range = %s - %s, want %s - %s", start, end, tt.start, tt.end)
+			}
+			if len(start) != tt.length || len(end) != tt.length {
+				t.Errorf("lengths = %d, %d; want %d", len(start), len(end), tt.length)
+			}
+		})
+	}
+}
+
+func TestParsePripsExclude(t *testing.T) {
+	tests := []struct {
+		spec    string
+		length  int
+		want    [][]byte // excluded values per octet
+		wantErr string
+	}{
+		{spec: "", length: net.IPv6len},
+		{spec: "...0,255", length: net.IPv4len, want: [][]byte{nil, nil, nil, {0, 255}}},
+		{spec: "10.1,2..", length: net.IPv4len, want: [][]byte{{10}, {1, 2}, nil, nil}},
+		{spec: "...", length: net.IPv4len, want: [][]byte{nil, nil, nil, nil}},
+		{spec: "...0", length: net.IPv6len, wantErr: "exclusions are only supported for IPv4"},
+		{spec: "..0", length: net.IPv4len, wantErr: "bad exclusion '..0': expected four dot-separated octets"},
+		{spec: "....0", length: net.IPv4len, wantErr: "expected four dot-separated octets"},
+		{spec: "...256", length: net.IPv4len, wantErr: "invalid octet '256'"},
+		{spec: "...-1", length: net.IPv4len, wantErr: "invalid octet '-1'"},
+		{spec: "...x", length: net.IPv4len, wantErr: "invalid octet 'x'"},
+		{spec: "...0,,1", length: net.IPv4len, wantErr: "invalid octet ''"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			exclude, err := parsePripsExclude(tt.spec, tt.length)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePripsExclude: %v", err)
+			}
+
+			var got [][]byte
+			for _, octets := range exclude {
+				var values []byte
+				for value := range octets {
+					values = append(values, value)
+				}
+				slices.Sort(values)
+				got = append(got, values)
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("parsePripsExclude = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The parsed exclusion drops matching addresses
+	exclude, err := parsePripsExclude("...0,255", net.IPv4len)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for addr, want := range map[string]bool{"10.0.1.0": true, "10.0.1.255": true, "10.0.1.1": false, "10.0.255.1": false} {
+		if got := excluded(net.ParseIP(addr).To4(), exclude); got != want {
+			t.Errorf("excluded(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderWatch     bool
+	renderTemplates string
+	renderOut       string
+	renderInterval  time.Duration
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Generate firewall artifacts from the config",
+	Long: titleStyle.Render("Render Artifacts") + "\n\n" +
+		"Write every configured export format for all config groups into a directory.\n" +
+		"Templates (*.tmpl) in --templates are rendered too, with the groups as data.\n" +
+		"Output is deterministic and files are only rewritten when their content changes,\n" +
+		"so the directory can be committed. With --watch, output is regenerated whenever\n" +
+		"the config or a template changes.\n\n" +
+		"Set 'formats' in the [render] config section to choose the built-in formats\n" +
+		"(comma-separated, empty for none). By default every format except the\n" +
+		"host-expanding service discovery formats is written. Set 'exclude-deprecated'\n" +
+		"to true to leave deprecated ranges out.",
+	Example: `  cidr render --out ./generated
+  cidr render --watch --templates ./templates --out ./generated`,
+	Args: cobra.NoArgs,
+	RunE: runRender,
+}
+
+func init() {
+	renderCmd.Flags().BoolVarP(&renderWatch, "watch", "w", false, "Keep running and regenerate when the config or templates change")
+	renderCmd.Flags().StringVarP(&renderTemplates, "templates", "t", "", "Directory of *.tmpl templates to render")
+	renderCmd.Flags().StringVarP(&renderOut, "out", "o", "generated", "Directory to write generated files to")
+	renderCmd.Flags().BoolVar(&excludeDeprecated, "exclude-deprecated", false, "Leave out ranges marked deprecated in the config")
+	renderCmd.Flags().DurationVar(&renderInterval, "interval", 2*time.Second, "How often to check for changes in watch mode")
+	rootCmd.AddCommand(renderCmd)
+}
+
+// renderData is passed to templates.
+type renderData struct {
+	Sets []exportSet
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	// Fingerprint before rendering so that an edit made while the first
+	// render runs is picked up by the watch loop
+	var last string
+	if renderWatch {
+		var err error
+		if last, err = renderFingerprint(); err != nil {
+			return err
+		}
+	}
+
+	changed, removed, err := renderAll()
+	if err != nil {
+		return err
+	}
+	reportRendered(changed, removed)
+
+	if !renderWatch {
+		return nil
+	}
+
+	fmt.Println(dimStyle.Render(fmt.Sprintf("Watching for changes every %s (Ctrl-C to stop)", renderInterval)))
+	for range time.Tick(renderInterval) {
+		current, err := renderFingerprint()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", errorStyle.Render("✗"), err)
+			continue
+		}
+		if current == last {
+			continue
+		}
+		last = current
+
+		changed, removed, err := renderAll()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", errorStyle.Render("✗"), err)
+			continue
+		}
+		reportRendered(changed, removed)
+	}
+
+	return nil
+}
+
+func reportRendered(changed, removed []string) {
+	stamp := time.Now().Format("15:04:05")
+	for _, path := range removed {
+		fmt.Printf("%s %s %s %s\n", dimStyle.Render(stamp), infoStyle.Render("○"), valueStyle.Render(path), dimStyle.Render("(removed, no longer generated)"))
+	}
+	if len(changed) == 0 && len(removed) == 0 {
+		fmt.Printf("%s %s\n", dimStyle.Render(stamp), infoStyle.Render("Up to date"))
+		return
+	}
+	for _, path := range changed {
+		fmt.Printf("%s %s %s\n", dimStyle.Render(stamp), successStyle.Render("✓"), valueStyle.Render(path))
+	}
+}
+
+// renderAll writes every configured format and template and returns the
+// files whose content changed and the stale outputs it removed.
+func renderAll() ([]string, []string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if value := cfg.value("render.exclude-deprecated"); value != "" {
+		exclude, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: invalid exclude-deprecated value '%s'", cfg.origin("render.exclude-deprecated"), value)
+		}
+		cfg.ExcludeDeprecated = cfg.ExcludeDeprecated || exclude
+	}
+
+	sets, err := cfg.exportSets()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := os.MkdirAll(renderOut, 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	var formats []string
+	for _, name := range exportFormatNames() {
+		if !exporters[name].Expands {
+			formats = append(formats, name)
+		}
+	}
+	if value, ok := cfg.Values["render.formats"]; ok {
+		formats = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				formats = append(formats, name)
+			}
+		}
+	}
+
+	var changed, produced []string
+	for _, name := range formats {
+		exp, ok := exporters[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: unknown render format '%s' (use %s)", cfg.origin("render.formats"), name, strings.Join(exportFormatNames(), ", "))
+		}
+
+		var buf bytes.Buffer
+		if err := exp.Write(&buf, sets); err != nil {
+			return nil, nil, err
+		}
+
+		path := filepath.Join(renderOut, exp.File)
+		written, err := writeIfChanged(path, buf.Bytes())
+		if err != nil {
+			return nil, nil, err
+		}
+		if written {
+			changed = append(changed, path)
+		}
+		produced = append(produced, exp.File)
+	}
+
+	templates, err := renderTemplateFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, tmplPath := range templates {
+		tmpl, err := template.New(filepath.Base(tmplPath)).Funcs(renderFuncs).ParseFiles(tmplPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, renderData{Sets: sets}); err != nil {
+			return nil, nil, err
+		}
+
+		name := strings.TrimSuffix(filepath.Base(tmplPath), ".tmpl")
+		path := filepath.Join(renderOut, name)
+		written, err := writeIfChanged(path, buf.Bytes())
+		if err != nil {
+			return nil, nil, err
+		}
+		if written {
+			changed = append(changed, path)
+		}
+		produced = append(produced, name)
+	}
+
+	removed, err := pruneRendered(renderOut, produced)
+	if err != nil {
+		return nil, nil, err
+	}
+	return changed, removed, nil
+}
+
+// renderManifest lists the files the last render wrote, so that outputs of
+// formats and templates that are no longer configured can be removed.
+const renderManifest = ".cidr-render"
+
+// pruneRendered removes the files listed in dir's manifest that are not in
+// produced, records produced as the new manifest and returns the removed
+// paths. Files the manifest does not list are never touched.
+func pruneRendered(dir string, produced []string) ([]string, error) {
+	manifestPath := filepath.Join(dir, renderManifest)
+	data, err := os.ReadFile(manifestPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	keep := make(map[string]bool, len(produced))
+	for _, name := range produced {
+		keep[name] = true
+	}
+	var removed []string
+	for _, name := range strings.Split(string(data), "\n") {
+		// Only plain file names are accepted, so a damaged manifest cannot
+		// point outside the directory
+		if name == "" || keep[name] || name != filepath.Base(name) || name == ".." || name == renderManifest {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if err := os.Remove(path); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		removed = append(removed, path)
+	}
+
+	names := slices.Sorted(maps.Keys(keep))
+	if _, err := writeIfChanged(manifestPath, []byte(strings.Join(names, "\n")+"\n")); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// renderFuncs are available to templates in addition to the builtins.
+var renderFuncs = template.FuncMap{
+	// export renders sets with a built-in exporter: {{ export "nftables" .Sets }}
+	"export": func(format string, sets []exportSet) (string, error) {
+		exp, ok := exporters[format]
+		if !ok {
+			return "", fmt.Errorf("unknown export format '%s'", format)
+		}
+		var buf bytes.Buffer
+		err := exp.Write(&buf, sets)
+		return buf.String(), err
+	},
+	// group selects sets by name: {{ export "nginx" (group .Sets "office") }}
+	"group": func(sets []exportSet, names ...string) []exportSet {
+		var selected []exportSet
+		for _, set := range sets {
+			for _, name := range names {
+				if set.Name == name {
+					selected = append(selected, set)
+				}
+			}
+		}
+		return selected
+	},
+}
+
+func renderTemplateFiles() ([]string, error) {
+	if renderTemplates == "" {
+		return nil, nil
+	}
+	files, err := filepath.Glob(filepath.Join(renderTemplates, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// renderFingerprint summarizes the modification state of the config and
+// templates so watch mode can detect changes by polling.
+func renderFingerprint() (string, error) {
+	layers, err := configLayers()
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	for _, layer := range layers {
+		files = append(files, layer.Path)
+	}
+
+	templates, err := renderTemplateFiles()
+	if err != nil {
+		return "", err
+	}
+	files = append(files, templates...)
+
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:missing;", file)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String(), nil
+}
+
+// writeIfChanged writes data to path unless the file already holds exactly
+// that content, and reports whether it wrote.
+func writeIfChanged(path string, data []byte) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRenderRemovesStaleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	templates := filepath.Join(dir, "templates")
+	out := filepath.Join(dir, "gen")
+	if err := os.Mkdir(templates, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	oldConfig, oldTemplates, oldOut := configFile, renderTemplates, renderOut
+	configFile, renderTemplates, renderOut = config, templates, out
+	t.Cleanup(func() { configFile, renderTemplates, renderOut = oldConfig, oldTemplates, oldOut })
+
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listing := func() []string {
+		t.Helper()
+		entries, err := os.ReadDir(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	write(config, "[render]\nformats = nftables, sg-json, nginx\n[group \"office\"]\n10.0.0.0/8\n")
+	write(filepath.Join(templates, "office.acl.tmpl"), "{{ range .Sets }}{{ .Name }}\n{{ end }}")
+	if _, removed, err := renderAll(); err != nil || len(removed) != 0 {
+		t.Fatalf("first render: removed %v, %v", removed, err)
+	}
+	// A file the renderer did not write is never removed
+	write(filepath.Join(out, "README"), "hand-written\n")
+
+	want := []string{".cidr-render", "README", "cidr.nft", "nginx-allow.conf", "office.acl", "security-group.json"}
+	if got := listing(); !slices.Equal(got, want) {
+		t.Fatalf("after first render: %v, want %v", got, want)
+	}
+
+	// Drop two formats and the template
+	write(config, "[render]\nformats = nginx\n[group \"office\"]\n10.0.0.0/8\n")
+	if err := os.Remove(filepath.Join(templates, "office.acl.tmpl")); err != nil {
+		t.Fatal(err)
+	}
+	_, removed, err := renderAll()
+	if err != nil {
+		t.Fatalf("second render: %v", err)
+	}
+	wantRemoved := []string{filepath.Join(out, "cidr.nft"), filepath.Join(out, "office.acl"), filepath.Join(out, "security-group.json")}
+	slices.Sort(removed)
+	if !slices.Equal(removed, wantRemoved) {
+		t.Errorf("removed %v, want %v", removed, wantRemoved)
+	}
+	want = []string{".cidr-render", "README", "nginx-allow.conf"}
+	if got := listing(); !slices.Equal(got, want) {
+		t.Errorf("after second render: %v, want %v", got, want)
+	}
+
+	// Nothing else to remove on the next run
+	if changed, removed, err := renderAll(); err != nil || len(changed) != 0 || len(removed) != 0 {
+		t.Errorf("third render: changed %v, removed %v, %v", changed, removed, err)
+	}
+}
+
+func TestPruneRenderedIgnoresPaths(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(dir, "outside")
+	out := filepath.Join(dir, "out")
+	for _, path := range []string{outside, out} {
+		if err := os.Mkdir(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "keep"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := "../outside/keep\n..\n" + outside + "/keep\n"
+	if err := os.WriteFile(filepath.Join(out, renderManifest), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := pruneRendered(out, nil)
+	if err != nil || len(removed) != 0 {
+		t.Errorf("pruneRendered = %v, %v; want nothing removed", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "keep")); err != nil {
+		t.Errorf("file outside the output directory: %v", err)
+	}
+}
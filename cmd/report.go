@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var reportHTML string
+
+//go:embed report.html
+var reportTemplate string
+
+var reportCmd = &cobra.Command{
+	Use:   "report [CIDR...] --html FILE",
+	Short: "Write a self-contained interactive HTML report",
+	Long: titleStyle.Render("HTML Report") + "\n\n" +
+		"Write a single HTML file that works offline in any browser, with:\n" +
+		"  - a searchable table of ranges with their groups and sizes\n" +
+		"  - a tree showing which ranges contain which\n" +
+		"  - an IP checker that matches addresses against the embedded ranges\n\n" +
+		"Uses the CIDRs given as arguments, the --group, or every group in the config.",
+	Example: `  cidr report --html ranges.html
+  cidr report --group office --html office.html`,
+	RunE: runReport,
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportHTML, "html", "", "Path of the HTML file to write")
+	reportCmd.MarkFlagRequired("html")
+	rootCmd.AddCommand(reportCmd)
+}
+
+// reportRange is one range as embedded in the report. Start and End are
+// hex so the page can compare addresses as BigInts. Parent indexes the
+// smallest other range containing this one, or is -1.
+type reportRange struct {
+	CIDR        string   `json:"cidr"`
+	Groups      []string `json:"groups"`
+	Family      int      `json:"family"`
+	First       string   `json:"first"`
+	Last        string   `json:"last"`
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	Size        string   `json:"size"`
+	Class       string   `json:"class"`
+	Deprecated  bool     `json:"deprecated"`
+	Replacement string   `json:"replacement,omitempty"`
+	Parent      int      `json:"parent"`
+}
+
+type reportData struct {
+	Title  string
+	Ranges []reportRange
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	sets, err := collectExportSets(args)
+	if err != nil {
+		return err
+	}
+	deprecated, err := loadDeprecations()
+	if err != nil {
+		return err
+	}
+
+	title := "CIDR ranges"
+	if configGroup != "" {
+		title += " - " + configGroup
+	}
+	data := reportData{Title: title, Ranges: reportRanges(sets, deprecated)}
+
+	tmpl, err := template.New("report").Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	if err := os.WriteFile(reportHTML, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Println(titleStyle.Render("HTML Report"))
+	fmt.Printf("%s %s\n", labelStyle.Render("Ranges:"), valueStyle.Render(fmt.Sprintf("%d in %d group(s)", len(data.Ranges), len(sets))))
+	fmt.Printf("%s %s\n", successStyle.Render("✓"), valueStyle.Render(reportHTML))
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr report --help' for more options"))
+
+	return nil
+}
+
+// reportRanges merges the sets into one canonical list, recording every
+// group a range appears in, and links each range to its closest container.
+func reportRanges(sets []exportSet, deprecated map[string]string) []reportRange {
+	groups := make(map[string][]string)
+	var cidrs []string
+	for _, set := range sets {
+		for _, ipnet := range set.CIDRs {
+			if _, ok := groups[ipnet.String()]; !ok {
+				cidrs = append(cidrs, ipnet.String())
+			}
+			groups[ipnet.String()] = append(groups[ipnet.String()], set.Name)
+		}
+	}
+	// Sets hold canonical ranges already, so this cannot fail
+	list, _ := canonicalCIDRList(cidrs)
+
+	ranges := make([]reportRange, len(list))
+	var stack []int // Ancestors of the current range, outermost first
+	for i, ipnet := range list {
+		ones, bits := ipnet.Mask.Size()
+		network, broadcast := ipnet.IP, getBroadcastIP(ipnet)
+		family := 6
+		if bits == 32 {
+			family = 4
+			network = network.To4()
+		}
+
+		replacement, isDeprecated := deprecated[ipnet.String()]
+		ranges[i] = reportRange{
+			CIDR:        ipnet.String(),
+			Groups:      groups[ipnet.String()],
+			Family:      family,
+			First:       network.String(),
+			Last:        broadcast.String(),
+			Start:       hex.EncodeToString(network),
+			End:         hex.EncodeToString(broadcast),
+			Size:        new(big.Int).Lsh(big.NewInt(1), uint(bits-ones)).String(),
+			Class:       classifyIP(network),
+			Deprecated:  isDeprecated,
+			Replacement: replacement,
+			Parent:      -1,
+		}
+		sort.Strings(ranges[i].Groups)
+
+		// Ranges are sorted by address then prefix length, so containers
+		// always come before the ranges they contain
+		for len(stack) > 0 && !list[stack[len(stack)-1]].Contains(ipnet.IP) {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			ranges[i].Parent = stack[len(stack)-1]
+		}
+		stack = append(stack, i)
+	}
+
+	return ranges
+}
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	resolvePlain     bool
+	resolveAzureTags string
+	resolveAWSRegion string
+
+	awsPrefixListPattern   = regexp.MustCompile(`^pl-[0-9a-f]{8,17}$`)
+	azureServiceTagPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(\.[A-Za-z][A-Za-z0-9]*)?$`) // Name or Name.Region
+
+	// Parsed service tag files, keyed by path or URL
+	azureServiceTagCache = make(map[string]*azureServiceTags)
+)
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve [name...]",
+	Short: "Resolve service tags, prefix lists and groups to CIDRs",
+	Long: titleStyle.Render("Resolve Named Ranges") + "\n\n" +
+		"Resolve symbolic names to their constituent prefixes:\n" +
+		"  - Azure service tags such as AzureCloud.WestEurope (from a service tags JSON file)\n" +
+		"  - AWS managed prefix list IDs such as pl-0123456789abcdef0 (via the aws CLI)\n" +
+		"  - Groups defined in the config file\n\n" +
+		"The same names can be used with --group and as entries in config groups.",
+	Example: `  cidr resolve AzureCloud.WestEurope --azure-service-tags ServiceTags_Public.json
+  cidr resolve pl-0123456789abcdef0 --aws-region eu-west-1
+  cidr resolve office --plain`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runResolve,
+}
+
+func init() {
+	resolveCmd.Flags().BoolVar(&resolvePlain, "plain", false, "Print only the prefixes, one per line")
+	resolveCmd.Flags().StringVar(&resolveAzureTags, "azure-service-tags", "", "Path or URL of an Azure service tags JSON file (overrides the config)")
+	resolveCmd.Flags().StringVar(&resolveAWSRegion, "aws-region", "", "AWS region for prefix list lookups (overrides the config)")
+	rootCmd.AddCommand(resolveCmd)
+}
+
+func runResolve(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for i, name := range args {
+		var cidrs []string
+		var source string
+		if cfg != nil && cfg.hasGroup(name) {
+			cidrs, err = cfg.groupCIDRs(name)
+			source = "group in " + cfg.Path
+		} else if isSymbolicName(cfg, name) {
+			cidrs, source, err = resolveName(cfg, name)
+		} else {
+			err = unknownNameError(cfg, name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if resolvePlain {
+			for _, cidr := range cidrs {
+				fmt.Println(cidr)
+			}
+			continue
+		}
+
+		if i > 0 {
+			fmt.Println() // Separator between multiple names
+		}
+		fmt.Println(titleStyle.Render("Resolved Prefixes"))
+		fmt.Printf("%s %s\n", labelStyle.Render("Name:"), valueStyle.Render(name))
+		fmt.Printf("%s %s\n", labelStyle.Render("Source:"), valueStyle.Render(source))
+		fmt.Printf("%s %s\n\n", labelStyle.Render("Prefixes:"), valueStyle.Render(fmt.Sprintf("%d", len(cidrs))))
+		for _, cidr := range cidrs {
+			fmt.Printf("  %s\n", valueStyle.Render(cidr))
+		}
+	}
+
+	if !resolvePlain {
+		fmt.Println()
+		fmt.Println(helpStyle.Render("Run 'cidr resolve --help' for more options"))
+	}
+
+	return nil
+}
+
+// isSymbolicName reports whether a range entry names a service tag or
+// prefix list rather than being a literal CIDR or IP. A word is only taken
+// for an Azure service tag when a service tags file is configured, so stray
+// words in a range file are reported as invalid ranges instead of being
+// looked up. cfg may be nil when no config is loaded.
+func isSymbolicName(cfg *cidrConfig, s string) bool {
+	if net.ParseIP(s) != nil {
+		return false
+	}
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return false
+	}
+	if awsPrefixListPattern.MatchString(s) {
+		return true
+	}
+	return azureServiceTagPattern.MatchString(s) && azureServiceTagsLocation(cfg) != ""
+}
+
+// unknownNameError explains that name is neither a config group nor a
+// service tag or prefix list that can be resolved.
+func unknownNameError(cfg *cidrConfig, name string) error {
+	msg := fmt.Sprintf("unknown group '%s'", name)
+	if cfg != nil {
+		if len(cfg.Groups) > 0 {
+			msg += fmt.Sprintf(" (groups in %s: %s)", cfg.Path, strings.Join(cfg.Groups, ", "))
+		} else {
+			msg += fmt.Sprintf(" (no groups in %s)", cfg.Path)
+		}
+	}
+	if azureServiceTagPattern.MatchString(name) && azureServiceTagsLocation(cfg) == "" {
+		msg += "; if it is an Azure service tag, set azure-service-tags in the [resolve] section of the config"
+	}
+	return errors.New(msg)
+}
+
+// resolveName expands a service tag or prefix list ID into its prefixes and
+// describes where they came from. cfg may be nil when no config is loaded.
+func resolveName(cfg *cidrConfig, name string) ([]string, string, error) {
+	if awsPrefixListPattern.MatchString(name) {
+		return resolveAWSPrefixList(cfg, name)
+	}
+	return resolveAzureServiceTag(cfg, name)
+}
+
+// azureServiceTags is the layout of Microsoft's ServiceTags_Public JSON.
+type azureServiceTags struct {
+	ChangeNumber int `json:"changeNumber"`
+	Values       []struct {
+		Name       string `json:"name"`
+		ID         string `json:"id"`
+		Properties struct {
+			AddressPrefixes []string `json:"addressPrefixes"`
+		} `json:"properties"`
+	} `json:"values"`
+}
+
+// azureServiceTagsLocation returns the service tags file from the
+// --azure-service-tags flag or the config, or an empty string when neither
+// sets one.
+func azureServiceTagsLocation(cfg *cidrConfig) string {
+	if resolveAzureTags != "" {
+		return resolveAzureTags
+	}
+	return cfg.value("resolve.azure-service-tags")
+}
+
+func resolveAzureServiceTag(cfg *cidrConfig, name string) ([]string, string, error) {
+	location := azureServiceTagsLocation(cfg)
+	if location == "" {
+		return nil, "", fmt.Errorf("cannot resolve '%s': no Azure service tags file configured "+
+			"(set azure-service-tags in the [resolve] section of the config; "+
+			"download it from https://www.microsoft.com/download/details.aspx?id=56519)", name)
+	}
+
+	timeout, err := sourceTimeout(cfg, "azure")
+	if err != nil {
+		return nil, "", err
+	}
+	tags, err := loadAzureServiceTags(location, timeout)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not load Azure service tags from %s: %w", location, timeoutError("azure", timeout, err))
+	}
+
+	for _, value := range tags.Values {
+		if strings.EqualFold(value.Name, name) || strings.EqualFold(value.ID, name) {
+			source := fmt.Sprintf("Azure service tags %s (change %d)", location, tags.ChangeNumber)
+			return value.Properties.AddressPrefixes, source, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("unknown Azure service tag '%s' in %s", name, location)
+}
+
+func loadAzureServiceTags(location string, timeout time.Duration) (*azureServiceTags, error) {
+	if tags, ok := azureServiceTagCache[location]; ok {
+		return tags, nil
+	}
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		data, err = fetchURL(location, timeout)
+	} else {
+		data, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tags azureServiceTags
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+
+	azureServiceTagCache[location] = &tags
+	return &tags, nil
+}
+
+func resolveAWSPrefixList(cfg *cidrConfig, id string) ([]string, string, error) {
+	region := resolveAWSRegion
+	if region == "" {
+		region = cfg.value("resolve.aws-region")
+	}
+
+	args := []string{"ec2", "get-managed-prefix-list-entries", "--prefix-list-id", id, "--output", "json"}
+	if region != "" {
+		args = append(args, "--region", region)
+	}
+	if profile := cfg.value("resolve.aws-profile"); profile != "" {
+		args = append(args, "--profile", profile)
+	}
+
+	timeout, err := sourceTimeout(cfg, "aws")
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	aws := exec.CommandContext(ctx, "aws", args...)
+	aws.Stdout = &stdout
+	aws.Stderr = &stderr
+	// Don't wait for children of the CLI that still hold its output open
+	aws.WaitDelay = time.Second
+	if err := aws.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, "", fmt.Errorf("could not resolve prefix list %s: %w", id, timeoutError("aws", timeout, ctx.Err()))
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, "", fmt.Errorf("could not resolve prefix list %s: %s", id, msg)
+		}
+		return nil, "", fmt.Errorf("could not resolve prefix list %s: %w", id, err)
+	}
+
+	var result struct {
+		Entries []struct {
+			Cidr string `json:"Cidr"`
+		} `json:"Entries"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return nil, "", fmt.Errorf("invalid aws CLI output for %s: %w", id, err)
+	}
+
+	cidrs := make([]string, len(result.Entries))
+	for i, entry := range result.Entries {
+		cidrs[i] = entry.Cidr
+	}
+
+	source := "AWS managed prefix list"
+	if region != "" {
+		source += " (" + region + ")"
+	}
+	return cidrs, source, nil
+}
+
+// fetchURL downloads a document, failing on non-200 responses.
+func fetchURL(url string, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
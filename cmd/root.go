@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
 	"net"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -12,8 +16,10 @@ import (
 )
 
 var (
-	checkIP    string
-	configFile string
+	checkIP           string
+	configFile        string
+	configGroup       string
+	excludeDeprecated bool
 
 	// Styles
 	titleStyle = lipgloss.NewStyle().
@@ -53,20 +59,34 @@ var rootCmd = &cobra.Command{
 	Long: titleStyle.Render("CIDR Parser") + "\n\n" +
 		"Parse CIDR subnet masks and display human-readable IP ranges.\n" +
 		"Check if an IP address belongs to a CIDR range.\n" +
-		"Load default CIDRs from ~/.cidr file.",
+		"Load default CIDRs from ~/.cidr file.\n" +
+		"Use --group to select a named group, service tag or prefix list.",
 	Example: `  cidr 192.168.1.0/24
   cidr 10.0.0.0/8 --check 10.5.3.2
-  cidr --check 172.16.0.5`,
+  cidr --check 172.16.0.5
+  cidr --check 172.16.0.5 --group office`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runCIDR,
+	// Execute prints errors itself with errorStyle
+	SilenceErrors: true,
 }
 
 func init() {
 	rootCmd.Flags().StringVarP(&checkIP, "check", "c", "", "Check if an IP address is within the CIDR range")
-	rootCmd.Flags().StringVarP(&configFile, "config", "f", "", "Path to .cidr config file (defaults to ~/.cidr)")
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "f", "", "Path to a config file (replaces the default config cascade)")
+	rootCmd.PersistentFlags().StringVarP(&configGroup, "group", "g", "", "Use only this config group, service tag or prefix list")
 }
 
 func Execute() {
+	name := invokedName()
+	if cmd, ok := multiCallCommands[name]; ok {
+		if err := cmd.Execute(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, errorStyle.Render("Error: ")+err.Error())
 		os.Exit(1)
@@ -83,15 +103,34 @@ func runCIDR(cmd *cobra.Command, args []string) error {
 		cidrs = append(cidrs, args[0])
 	}
 
-	// Load CIDRs from config file if no argument provided or if checking an IP
+	// Load CIDRs from config file if no argument provided or if checking an IP.
+	// The same config supplies deprecations and score weights for --check.
+	var cfg *cidrConfig
 	if len(cidrs) == 0 || checkIP != "" {
-		configCIDRs, path, err := loadConfigCIDRs()
+		var groupRanges []string
+		var path string
+		loaded, err := loadConfig()
+		switch {
+		case err == nil:
+			cfg = loaded
+			groupRanges, path, err = configCIDRs(cfg)
+		case symbolicGroup():
+			groupRanges, path, err = resolveName(nil, configGroup)
+		}
 		if err == nil {
-			cidrs = append(cidrs, configCIDRs...)
+			cidrs = append(cidrs, groupRanges...)
 			configPath = path
 			configLoaded = true
 		} else if len(cidrs) == 0 {
 			return fmt.Errorf("no CIDR provided and could not load config file: %w", err)
+		} else {
+			// The ranges came from arguments, so a broken config only
+			// loses the extras
+			if !errors.Is(err, fs.ErrNotExist) {
+				fmt.Println(dimStyle.Render(fmt.Sprintf("Ignoring config: %v", err)))
+				fmt.Println()
+			}
+			cfg = nil
 		}
 	}
 
@@ -107,9 +146,31 @@ func runCIDR(cmd *cobra.Command, args []string) error {
 
 	// If checking an IP, validate and check against CIDRs
 	if checkIP != "" {
-		if err := checkIPInCIDRs(checkIP, cidrs); err != nil {
+		var deprecated map[string]string
+		var scorer *ipScorer
+		if cfg != nil {
+			var err error
+			if deprecated, err = cfg.deprecations(); err == nil {
+				scorer, err = newScorer(cfg)
+			}
+			if err != nil {
+				if len(args) == 0 {
+					return err
+				}
+				fmt.Println(dimStyle.Render(fmt.Sprintf("Ignoring config: %v", err)))
+				fmt.Println()
+				deprecated, scorer = nil, nil
+			}
+		}
+		if err := checkIPInCIDRs(checkIP, cidrs, deprecated); err != nil {
 			return err
 		}
+
+		// Show the reputation score when weights are configured
+		if scorer != nil {
+			fmt.Println()
+			displayScore(scorer, net.ParseIP(checkIP))
+		}
 	} else {
 		// Otherwise, display CIDR information
 		for i, cidr := range cidrs {
@@ -130,7 +191,7 @@ func runCIDR(cmd *cobra.Command, args []string) error {
 }
 
 func displayCIDRInfo(cidrStr string) error {
-	_, ipnet, err := net.ParseCIDR(cidrStr)
+	ip, ipnet, err := net.ParseCIDR(cidrStr)
 	if err != nil {
 		return fmt.Errorf("invalid CIDR notation '%s': %w", cidrStr, err)
 	}
@@ -149,6 +210,9 @@ func displayCIDRInfo(cidrStr string) error {
 	// Display information
 	fmt.Println(titleStyle.Render("CIDR Information"))
 	fmt.Printf("%s %s\n", labelStyle.Render("CIDR:"), valueStyle.Render(cidrStr))
+	if !ip.Equal(networkIP) {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("Warning: %s is not on a network boundary, showing %s (see 'cidr aligned')", ip, ipnet)))
+	}
 	fmt.Printf("%s %s\n", labelStyle.Render("Network Address:"), valueStyle.Render(networkIP.String()))
 	fmt.Printf("%s %s\n", labelStyle.Render("Subnet Mask:"), valueStyle.Render(mask.String()))
 	fmt.Printf("%s %s\n", labelStyle.Render("Broadcast Address:"), valueStyle.Render(broadcastIP.String()))
@@ -162,14 +226,16 @@ func displayCIDRInfo(cidrStr string) error {
 	return nil
 }
 
-func checkIPInCIDRs(ipStr string, cidrs []string) error {
+func checkIPInCIDRs(ipStr string, cidrs []string, deprecated map[string]string) error {
 	ip := net.ParseIP(ipStr)
 	if ip == nil {
 		return fmt.Errorf("invalid IP address: %s", ipStr)
 	}
 
 	fmt.Println(titleStyle.Render("IP Address Check"))
-	fmt.Printf("%s %s\n\n", labelStyle.Render("Checking IP:"), valueStyle.Render(ipStr))
+	fmt.Printf("%s %s\n", labelStyle.Render("Checking IP:"), valueStyle.Render(ipStr))
+	displayInterfaceID(ip)
+	fmt.Println()
 
 	found := false
 	for _, cidrStr := range cidrs {
@@ -182,6 +248,13 @@ func checkIPInCIDRs(ipStr string, cidrs []string) error {
 		if ipnet.Contains(ip) {
 			fmt.Printf("%s IP is in %s\n", successStyle.Render("✓"), valueStyle.Render(cidrStr))
 			found = true
+			if replacement, ok := deprecated[ipnet.String()]; ok {
+				warning := fmt.Sprintf("  ⚠ %s is deprecated", cidrStr)
+				if replacement != "" {
+					warning += ", use " + replacement
+				}
+				fmt.Println(infoStyle.Render(warning))
+			}
 		} else {
 			fmt.Printf("%s IP is not in %s\n", infoStyle.Render("○"), cidrStr)
 		}
@@ -197,35 +270,32 @@ func checkIPInCIDRs(ipStr string, cidrs []string) error {
 	return nil
 }
 
-func loadConfigCIDRs() ([]string, string, error) {
-	var configPath string
-	if configFile != "" {
-		configPath = configFile
+// readListFile reads a file with one entry per line, skipping empty lines
+// and comments. A path of "-" reads from stdin.
+func readListFile(path string) ([]string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
 	} else {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, "", err
-		}
-		configPath = filepath.Join(home, ".cidr")
+		data, err = os.ReadFile(path)
 	}
-
-	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
 	lines := strings.Split(string(data), "\n")
-	var cidrs []string
+	var entries []string
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		// Skip empty lines and comments
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
-		cidrs = append(cidrs, line)
+		entries = append(entries, line)
 	}
 
-	return cidrs, configPath, nil
+	return entries, nil
 }
 
 // Helper functions for IP calculations
@@ -300,3 +370,74 @@ func getUsableHosts(ipnet *net.IPNet) uint64 {
 	}
 	return total - 2 // Subtract network and broadcast addresses
 }
+
+// compareIPs orders addresses with IPv4 before IPv6 and then numerically.
+func compareIPs(a, b net.IP) int {
+	a4, b4 := a.To4(), b.To4()
+	switch {
+	case a4 != nil && b4 != nil:
+		return bytes.Compare(a4, b4)
+	case a4 != nil:
+		return -1
+	case b4 != nil:
+		return 1
+	}
+	return bytes.Compare(a.To16(), b.To16())
+}
+
+// rangeToCIDRs returns the smallest list of CIDR blocks that exactly covers
+// the inclusive address range start-end.
+func rangeToCIDRs(start, end net.IP) []*net.IPNet {
+	bits := 128
+	if start.To4() != nil && end.To4() != nil {
+		start, end = start.To4(), end.To4()
+		bits = 32
+	} else {
+		start, end = start.To16(), end.To16()
+	}
+
+	lo := new(big.Int).SetBytes(start)
+	hi := new(big.Int).SetBytes(end)
+	one := big.NewInt(1)
+
+	var cidrs []*net.IPNet
+	for lo.Cmp(hi) <= 0 {
+		// Grow the block while it stays aligned and inside the range
+		size := 0
+		for size < bits {
+			if lo.Bit(size) != 0 {
+				break
+			}
+			last := new(big.Int).Lsh(one, uint(size+1))
+			last.Add(last, lo).Sub(last, one)
+			if last.Cmp(hi) > 0 {
+				break
+			}
+			size++
+		}
+
+		ip := make(net.IP, len(start))
+		lo.FillBytes(ip)
+		cidrs = append(cidrs, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits-size, bits)})
+
+		lo.Add(lo, new(big.Int).Lsh(one, uint(size)))
+	}
+
+	return cidrs
+}
+
+// nextIP returns the address following ip, wrapping at the end of the space.
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+
+	// Increment IP by 1
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] > 0 {
+			break
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ipScorer combines weighted signals into a single 0-100 score. Weights come
+// from the [score] config section:
+//
+//	[score]
+//	bogon = 40              # any non-global address
+//	class.documentation = 10
+//	group.deny = 60         # membership in a config group
+//	group.office = -50
+type ipScorer struct {
+	weights map[string]float64
+	matcher *rangeMatcher
+}
+
+// scoreSignal is a signal that fired for an address and its weight.
+type scoreSignal struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+// newScorer builds a scorer from the config. It returns nil when no weights
+// are configured, which disables scoring.
+func newScorer(cfg *cidrConfig) (*ipScorer, error) {
+	weights := make(map[string]float64)
+	for key, value := range cfg.Values {
+		signal, ok := strings.CutPrefix(key, "score.")
+		if !ok {
+			continue
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid score weight '%s' for %s", cfg.origin(key), value, signal)
+		}
+		weights[signal] = weight
+	}
+	if len(weights) == 0 {
+		return nil, nil
+	}
+
+	sets, err := cfg.exportSets()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ipScorer{weights: weights, matcher: newRangeMatcher(sets)}, nil
+}
+
+// loadScorer loads the config and builds a scorer, returning nil when there
+// is no config or no weights in it.
+func loadScorer() (*ipScorer, error) {
+	cfg, err := loadConfig()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newScorer(cfg)
+}
+
+// score returns the clamped score for ip and the weighted signals that fired,
+// highest weight first.
+func (s *ipScorer) score(ip net.IP) (int, []scoreSignal) {
+	class := classifyIP(ip)
+	names := []string{"class." + class}
+	if class != "global" {
+		names = append(names, "bogon")
+	}
+	for _, group := range matchedGroups(s.matcher.match(ip)) {
+		names = append(names, "group."+group)
+	}
+
+	total := 0.0
+	var signals []scoreSignal
+	for _, name := range names {
+		// Config keys are case-insensitive
+		weight, ok := s.weights[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		total += weight
+		signals = append(signals, scoreSignal{Name: name, Weight: weight})
+	}
+
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].Weight > signals[j].Weight })
+
+	return int(math.Round(math.Max(0, math.Min(100, total)))), signals
+}
+
+func displayScore(scorer *ipScorer, ip net.IP) {
+	score, signals := scorer.score(ip)
+
+	parts := make([]string, len(signals))
+	for i, signal := range signals {
+		parts[i] = fmt.Sprintf("%s %+g", signal.Name, signal.Weight)
+	}
+	detail := "no weighted signals"
+	if len(parts) > 0 {
+		detail = strings.Join(parts, ", ")
+	}
+
+	style := successStyle
+	switch {
+	case score >= 70:
+		style = errorStyle
+	case score >= 30:
+		style = infoStyle
+	}
+
+	fmt.Printf("%s %s %s\n", labelStyle.Render("Score:"), style.Render(strconv.Itoa(score)), dimStyle.Render("("+detail+")"))
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	shadowEngineA       string
+	shadowEngineB       string
+	shadowInput         string
+	shadowDuration      time.Duration
+	shadowMaxMismatches int
+)
+
+var shadowCmd = &cobra.Command{
+	Use:   "shadow [CIDR...]",
+	Short: "Compare two matching engines on a list of queries",
+	Long: titleStyle.Render("Shadow Comparison") + "\n\n" +
+		"Run every query IP through two range matching engines, compare the ranges\n" +
+		"each one returns, and report mismatches and relative performance. Matches\n" +
+		"against the CIDRs given as arguments, the --group, or every config range.\n\n" +
+		"With --duration the queries are replayed until the time is up (or Ctrl-C),\n" +
+		"for soak testing a new engine. Exits non-zero when any result differs.\n\n" +
+		"Engines: " + strings.Join(slices.Sorted(maps.Keys(matchEngines)), ", "),
+	Example: `  cidr shadow --engine-a linear --engine-b trie --input queries.txt
+  cidr shadow --input queries.txt --duration 10m --group office
+  awk '{print $1}' access.log | cidr shadow --input -`,
+	RunE: runShadow,
+}
+
+func init() {
+	shadowCmd.Flags().StringVar(&shadowEngineA, "engine-a", "linear", "Reference engine")
+	shadowCmd.Flags().StringVar(&shadowEngineB, "engine-b", "trie", "Engine to compare against the reference")
+	shadowCmd.Flags().StringVarP(&shadowInput, "input", "i", "", "File of query IPs, one per line ('-' for stdin)")
+	shadowCmd.Flags().DurationVar(&shadowDuration, "duration", 0, "Replay the queries until this much time has passed (default one pass)")
+	shadowCmd.Flags().IntVar(&shadowMaxMismatches, "max-mismatches", 20, "Number of mismatching queries to show")
+	shadowCmd.MarkFlagRequired("input")
+	rootCmd.AddCommand(shadowCmd)
+}
+
+// shadowEngine is one side of the comparison with its accumulated timings.
+type shadowEngine struct {
+	Name   string
+	Engine matchEngine
+	Build  time.Duration
+	Total  time.Duration
+}
+
+func newShadowEngine(name string, sets []exportSet) (*shadowEngine, error) {
+	build, ok := matchEngines[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown engine '%s' (available: %s)", name, strings.Join(slices.Sorted(maps.Keys(matchEngines)), ", "))
+	}
+	start := time.Now()
+	engine := build(sets)
+	return &shadowEngine{Name: name, Engine: engine, Build: time.Since(start)}, nil
+}
+
+// run matches every query and adds the time taken to the engine's total.
+func (e *shadowEngine) run(queries []net.IP) [][]matcherEntry {
+	results := make([][]matcherEntry, len(queries))
+	start := time.Now()
+	for i, ip := range queries {
+		results[i] = e.Engine.match(ip)
+	}
+	e.Total += time.Since(start)
+	return results
+}
+
+func runShadow(cmd *cobra.Command, args []string) error {
+	sets, err := collectExportSets(args)
+	if err != nil {
+		return err
+	}
+	engineA, err := newShadowEngine(shadowEngineA, sets)
+	if err != nil {
+		return err
+	}
+	engineB, err := newShadowEngine(shadowEngineB, sets)
+	if err != nil {
+		return err
+	}
+
+	lines, err := readListFile(shadowInput)
+	if err != nil {
+		return err
+	}
+	queries := make([]net.IP, len(lines))
+	for i, line := range lines {
+		if queries[i] = net.ParseIP(line); queries[i] == nil {
+			return fmt.Errorf("invalid IP address: %s", line)
+		}
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("no queries in %s", shadowInput)
+	}
+
+	ranges := 0
+	for _, set := range sets {
+		ranges += len(set.CIDRs)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Mismatches are recorded once per query; engines are expected to be
+	// deterministic, so later passes only add to the count.
+	mismatched := make(map[int]bool)
+	var shown []int
+	var resultsA, resultsB [][]matcherEntry
+	mismatches, passes := 0, 0
+	start, lastProgress := time.Now(), time.Now()
+	for {
+		// Alternate which engine runs first so neither always gets a warm cache
+		if passes%2 == 0 {
+			resultsA, resultsB = engineA.run(queries), engineB.run(queries)
+		} else {
+			resultsB, resultsA = engineB.run(queries), engineA.run(queries)
+		}
+		passes++
+
+		for i := range queries {
+			if sameEntries(resultsA[i], resultsB[i]) {
+				continue
+			}
+			mismatches++
+			if !mismatched[i] {
+				mismatched[i] = true
+				if len(shown) < shadowMaxMismatches {
+					shown = append(shown, i)
+				}
+			}
+		}
+
+		if shadowDuration == 0 || time.Since(start) >= shadowDuration || ctx.Err() != nil {
+			break
+		}
+		if time.Since(lastProgress) >= 10*time.Second {
+			lastProgress = time.Now()
+			fmt.Fprintln(os.Stderr, dimStyle.Render(fmt.Sprintf("%s: %d passes, %d mismatches",
+				time.Since(start).Round(time.Second), passes, mismatches)))
+		}
+	}
+
+	comparisons := passes * len(queries)
+	fmt.Println(titleStyle.Render("Shadow Comparison"))
+	fmt.Printf("%s %s\n", labelStyle.Render("Ranges:"), valueStyle.Render(fmt.Sprintf("%d in %d group(s)", ranges, len(sets))))
+	fmt.Printf("%s %s\n", labelStyle.Render("Queries:"), valueStyle.Render(fmt.Sprintf("%d × %d pass(es) = %d comparisons", len(queries), passes, comparisons)))
+	fmt.Println()
+	for _, e := range []*shadowEngine{engineA, engineB} {
+		perQuery := e.Total / time.Duration(comparisons)
+		fmt.Printf("%s %s\n", labelStyle.Render(e.Name+":"), valueStyle.Render(fmt.Sprintf("build %s, total %s, %s/query",
+			e.Build.Round(time.Microsecond), e.Total.Round(time.Microsecond), perQuery)))
+	}
+
+	if len(shown) > 0 {
+		fmt.Println()
+		for _, i := range shown {
+			fmt.Printf("%s %s\n", errorStyle.Render("✗"), valueStyle.Render(queries[i].String()))
+			fmt.Printf("    %s %s\n", dimStyle.Render(engineA.Name+":"), formatEntries(resultsA[i]))
+			fmt.Printf("    %s %s\n", dimStyle.Render(engineB.Name+":"), formatEntries(resultsB[i]))
+		}
+		if more := len(mismatched) - len(shown); more > 0 {
+			fmt.Println(dimStyle.Render(fmt.Sprintf("  ... and %d more", more)))
+		}
+	}
+
+	fmt.Println()
+	if engineA.Total > 0 && engineB.Total > 0 {
+		if engineB.Total <= engineA.Total {
+			fmt.Println(infoStyle.Render(fmt.Sprintf("%s was %.1fx faster than %s", engineB.Name, float64(engineA.Total)/float64(engineB.Total), engineA.Name)))
+		} else {
+			fmt.Println(infoStyle.Render(fmt.Sprintf("%s was %.1fx slower than %s", engineB.Name, float64(engineB.Total)/float64(engineA.Total), engineA.Name)))
+		}
+	}
+	if mismatches > 0 {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%d of %d queries differ (%d of %d comparisons)", len(mismatched), len(queries), mismatches, comparisons)))
+		cmd.SilenceUsage = true
+		return fmt.Errorf("engines %s and %s disagree", engineA.Name, engineB.Name)
+	}
+
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ No mismatches in %d comparisons", comparisons)))
+	fmt.Println()
+	fmt.Println(helpStyle.Render("Run 'cidr shadow --help' for more options"))
+
+	return nil
+}
+
+// sameEntries reports whether two engines returned the same ranges, in any
+// order.
+func sameEntries(a, b []matcherEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return slices.Equal(entryKeys(a), entryKeys(b))
+}
+
+func entryKeys(entries []matcherEntry) []string {
+	keys := make([]string, len(entries))
+	for i, entry := range entries {
+		keys[i] = entry.Net.String() + " (" + entry.Group + ")"
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func formatEntries(entries []matcherEntry) string {
+	if len(entries) == 0 {
+		return "(no match)"
+	}
+	return strings.Join(entryKeys(entries), ", ")
+}
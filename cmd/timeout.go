@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Every network source has its own time limit so that one slow registry
+// or endpoint fails fast with an error naming it instead of stalling the
+// whole command. The limit comes from --timeout, then timeout.<source> in
+// the config, then timeout.default, then the source's built-in default:
+//
+//	[timeout]
+//	default = 10s
+//	rdap = 5s
+var networkSources = map[string]struct {
+	Description string
+	Default     time.Duration
+}{
+	"rdap":  {"RDAP lookup", 15 * time.Second},
+	"azure": {"Azure service tags download", 30 * time.Second},
+	"aws":   {"aws CLI", 60 * time.Second},
+	"dns":   {"DNS lookup", 5 * time.Second},
+}
+
+var globalTimeout time.Duration
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&globalTimeout, "timeout", 0, "Time limit for each network request (overrides the config's [timeout] section)")
+}
+
+// sourceTimeout returns the time limit for a network source. cfg may be nil
+// when no config is loaded.
+func sourceTimeout(cfg *cidrConfig, source string) (time.Duration, error) {
+	if globalTimeout > 0 {
+		return globalTimeout, nil
+	}
+	for _, key := range []string{"timeout." + source, "timeout.default"} {
+		value := cfg.value(key)
+		if value == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return 0, fmt.Errorf("%s: invalid timeout '%s' (use a duration such as 10s)", cfg.origin(key), value)
+		}
+		return timeout, nil
+	}
+	return networkSources[source].Default, nil
+}
+
+// timeoutError replaces a timeout from a network source with an error that
+// names the source and its limit. Other errors are returned unchanged.
+func timeoutError(source string, timeout time.Duration, err error) error {
+	var netErr net.Error
+	if !errors.Is(err, context.DeadlineExceeded) && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		return err
+	}
+	return fmt.Errorf("%s timed out after %s (set timeout.%s in the config or use --timeout)",
+		networkSources[source].Description, timeout, source)
+}